@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
@@ -10,14 +11,47 @@ import (
 
 	"github.com/VxVxN/financialanalyzer/internal/config"
 	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/fx"
+	"github.com/VxVxN/financialanalyzer/internal/importer"
+	"github.com/VxVxN/financialanalyzer/internal/models"
 	"github.com/VxVxN/financialanalyzer/internal/parser"
 )
 
+type options struct {
+	dryRun            bool
+	force             bool
+	mode              string
+	diagnosticsFormat string
+	maxErrors         int
+}
+
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	// Logs go to stderr so that diagnostics and dry-run reports on stdout stay machine-readable.
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
 
 	cfg := config.LoadConfig()
 
+	var opts options
+	flag.StringVar(&cfg.MappingPath, "mapping", cfg.MappingPath, "path to a custom CSV metric mapping file (JSON)")
+	flag.StringVar(&cfg.FXRatesPath, "fx-rates", cfg.FXRatesPath, "path to a CSV of FX rates (date;currency;rate in RUB) to load before importing")
+	flag.IntVar(&cfg.ParseWorkers, "workers", cfg.ParseWorkers, "number of files parsed concurrently")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "compare parsed data with the database without writing anything")
+	flag.StringVar(&opts.mode, "mode", string(models.ImportModeMerge), "import mode: merge keeps stored values missing from the file, replace clears them")
+	flag.BoolVar(&opts.force, "force", false, "re-import files even if their checksum has not changed")
+	flag.StringVar(&opts.diagnosticsFormat, "diagnostics", "table", "parse diagnostics output format: table or json")
+	flag.IntVar(&opts.maxErrors, "max-errors", -1, "fail the import when parse errors exceed this number (-1 disables the check)")
+	flag.Parse()
+
+	if opts.mode != string(models.ImportModeMerge) && opts.mode != string(models.ImportModeReplace) {
+		logger.Error("Invalid import mode", "mode", opts.mode)
+		os.Exit(2)
+	}
+
+	if opts.diagnosticsFormat != "table" && opts.diagnosticsFormat != "json" {
+		logger.Error("Invalid diagnostics format", "format", opts.diagnosticsFormat)
+		os.Exit(2)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -28,48 +62,223 @@ func main() {
 		cancel()
 	}()
 
-	if err := run(ctx, cfg, logger); err != nil {
+	if err := run(ctx, cfg, opts, logger); err != nil {
 		logger.Error("Application failed", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
+func run(ctx context.Context, cfg *config.Config, opts options, logger *slog.Logger) error {
 	db, err := database.NewConnection(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 	defer db.Close()
 
-	if err := database.RunMigrations(db); err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+	if err := database.CheckSchema(db); err != nil {
+		return fmt.Errorf("failed to check database schema: %w", err)
 	}
 
-	csvParser := parser.NewCSVParser(cfg.CSVPath, logger)
-	data, err := csvParser.Parse()
+	mapping, err := parser.LoadMapping(cfg.MappingPath)
 	if err != nil {
-		return fmt.Errorf("failed to parse CSV: %w", err)
+		return fmt.Errorf("failed to load metric mapping: %w", err)
 	}
 
 	repo := database.NewRepository(db)
 
-	for _, item := range data {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			if err := repo.SaveQuarterData(item); err != nil {
-				logger.Warn("Failed to save quarter data",
-					"company", item.Company,
-					"year", item.Year,
-					"quarter", item.Quarter,
-					"error", err)
-			}
+	if cfg.FXRatesPath != "" && !opts.dryRun {
+		if err := loadFXRates(ctx, repo, cfg.FXRatesPath, logger); err != nil {
+			return err
 		}
 	}
 
+	csvParser := parser.NewCSVParser(cfg.CSVPath, mapping, cfg.ParseWorkers, logger)
+	if !opts.force {
+		checksums, err := repo.GetImportedChecksums(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load file checksums: %w", err)
+		}
+		csvParser.SkipUnchanged(checksums)
+	}
+
+	result, err := csvParser.Parse(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	diagnostics := result.Diagnostics()
+	defer func() {
+		if err := diagnostics.Print(os.Stdout, opts.diagnosticsFormat); err != nil {
+			logger.Error("Failed to print diagnostics", "error", err)
+		}
+	}()
+
+	if opts.maxErrors >= 0 && diagnostics.Errors() > opts.maxErrors {
+		return fmt.Errorf("parse errors (%d) exceed the allowed maximum (%d)", diagnostics.Errors(), opts.maxErrors)
+	}
+
+	if err := resolveAliases(ctx, repo, result, logger); err != nil {
+		return err
+	}
+
+	data := result.Data()
+
+	if opts.dryRun {
+		return dryRun(ctx, repo, models.ImportMode(opts.mode), data)
+	}
+
+	runID, err := repo.StartImportRun(ctx, cfg.CSVPath)
+	if err != nil {
+		return fmt.Errorf("failed to start import run: %w", err)
+	}
+
+	importRun := database.ImportRun{
+		ID:           runID,
+		Status:       database.ImportRunCompleted,
+		FilesTotal:   len(result.Files),
+		FilesSkipped: result.Skipped(),
+		RowsParsed:   len(data),
+	}
+
+	importErr := importFiles(ctx, repo, models.ImportMode(opts.mode), &importRun, result.Files, logger)
+	if importErr != nil {
+		importRun.Status = database.ImportRunFailed
+		importRun.Error = importErr.Error()
+	} else if importRun.FilesFailed > 0 {
+		// Failed files make the whole run fail, so the exit code tells cron about them.
+		attempted := importRun.FilesTotal - importRun.FilesSkipped
+		importErr = fmt.Errorf("%d of %d files failed to import", importRun.FilesFailed, attempted)
+		importRun.Status = database.ImportRunFailed
+		if importRun.FilesFailed < attempted {
+			importRun.Status = database.ImportRunPartial
+		}
+		importRun.Error = importErr.Error()
+	}
+
+	// An interrupted import is still recorded as failed.
+	if err := repo.FinishImportRun(context.WithoutCancel(ctx), importRun); err != nil {
+		logger.Error("Failed to record import run", "run_id", runID, "error", err)
+	}
+
+	if importErr != nil {
+		return importErr
+	}
+
 	logger.Info("Data import completed successfully",
-		"records_processed", len(data))
+		"run_id", runID,
+		"records_processed", importRun.RowsParsed,
+		"records_written", importRun.RowsWritten,
+		"files_failed", importRun.FilesFailed,
+		"files_skipped", importRun.FilesSkipped)
+
+	return nil
+}
+
+func importFiles(ctx context.Context, repo database.Store, mode models.ImportMode, importRun *database.ImportRun,
+	files []parser.FileResult, logger *slog.Logger) error {
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		merged := importer.Merge(file.Data)
+		importFile := database.ImportFile{
+			RunID:         importRun.ID,
+			Path:          file.Path,
+			SHA256:        file.SHA256,
+			MappingSHA256: file.MappingSHA256,
+			Size:          file.Size,
+			RowsParsed:    len(merged),
+			Errors:        file.Diagnostics.Errors(),
+			Status:        database.ImportFileImported,
+		}
+
+		if file.Skipped {
+			importFile.Status = database.ImportFileSkipped
+		} else if file.Err != nil {
+			importFile.Status = database.ImportFileFailed
+			importRun.FilesFailed++
+		} else if err := repo.SaveQuarterDataBatch(ctx, importRun.ID, mode, []models.Company{file.Company.Company()}, merged); err != nil {
+			importFile.Status = database.ImportFileFailed
+			importRun.FilesFailed++
+			logger.Warn("Failed to save file data",
+				"path", file.Path,
+				"records", len(merged),
+				"error", err)
+		} else {
+			importRun.RowsWritten += len(merged)
+		}
+
+		// Without the record the file would lose its provenance and never be skipped as unchanged.
+		if err := repo.SaveImportFile(ctx, importFile); err != nil {
+			return fmt.Errorf("failed to record import file %s: %w", file.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func dryRun(ctx context.Context, repo database.FinancialsRepository, mode models.ImportMode, data []models.QuarterData) error {
+	companies := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, item := range data {
+		if !seen[item.Company] {
+			seen[item.Company] = true
+			companies = append(companies, item.Company)
+		}
+	}
+
+	existing, err := repo.GetQuarterData(ctx, companies)
+	if err != nil {
+		return fmt.Errorf("failed to load existing data: %w", err)
+	}
+
+	return importer.Diff(data, existing, mode).Print(os.Stdout)
+}
+
+func loadFXRates(ctx context.Context, repo database.FXRatesRepository, path string, logger *slog.Logger) error {
+	rates, err := fx.LoadCSV(path)
+	if err != nil {
+		return fmt.Errorf("failed to load fx rates: %w", err)
+	}
+
+	if err := repo.SaveFXRates(ctx, rates); err != nil {
+		return fmt.Errorf("failed to save fx rates: %w", err)
+	}
+
+	logger.Info("FX rates loaded", "path", path, "rates", len(rates))
+	return nil
+}
+
+// resolveAliases moves files imported under a renamed or merged company's old ticker onto the current one.
+func resolveAliases(ctx context.Context, repo database.AliasesRepository, result *parser.Result, logger *slog.Logger) error {
+	var tickers []string
+	for _, file := range result.Files {
+		tickers = append(tickers, file.Company.Ticker)
+	}
+
+	resolved, err := repo.ResolveAliases(ctx, tickers)
+	if err != nil {
+		return fmt.Errorf("failed to resolve company aliases: %w", err)
+	}
+
+	for i := range result.Files {
+		file := &result.Files[i]
+		ticker, ok := resolved[file.Company.Ticker]
+		if !ok {
+			continue
+		}
+
+		logger.Info("Importing file under company alias", "path", file.Path, "alias", file.Company.Ticker, "company", ticker)
+		if file.Company.Name == file.Company.Ticker {
+			file.Company.Name = ""
+		}
+		file.Company.Ticker = ticker
+		for j := range file.Data {
+			file.Data[j].Company = ticker
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/VxVxN/financialanalyzer/internal/config"
+	"github.com/VxVxN/financialanalyzer/internal/database"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up         apply all pending migrations
+  down N     roll back the last N migrations
+  goto V     migrate up or down to version V
+  version    print the current version
+  force V    set version V without running migrations, clearing the dirty flag (-1 for none)
+`
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+
+	if err := run(cfg, flag.Args(), logger); err != nil {
+		logger.Error("Migration failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg *config.Config, args []string, logger *slog.Logger) error {
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	apply, err := parseCommand(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	m.Log = migrationLogger{logger: logger}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		m.GracefulStop <- true
+	}()
+
+	if err := apply(m); errors.Is(err, migrate.ErrNoChange) {
+		logger.Info("No migrations to apply")
+	} else if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	return printVersion(m, logger)
+}
+
+func parseCommand(command string, args []string) (func(m *migrate.Migrate) error, error) {
+	switch command {
+	case "up":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("up takes no arguments")
+		}
+		return (*migrate.Migrate).Up, nil
+	case "down":
+		steps, err := intArg(command, args)
+		if err != nil {
+			return nil, err
+		}
+		if steps <= 0 {
+			return nil, fmt.Errorf("down needs a positive number of migrations, got %d", steps)
+		}
+		return func(m *migrate.Migrate) error { return m.Steps(-steps) }, nil
+	case "goto":
+		version, err := intArg(command, args)
+		if err != nil {
+			return nil, err
+		}
+		if version <= 0 {
+			return nil, fmt.Errorf("invalid version %d", version)
+		}
+		return func(m *migrate.Migrate) error { return m.Migrate(uint(version)) }, nil
+	case "force":
+		version, err := intArg(command, args)
+		if err != nil {
+			return nil, err
+		}
+		return func(m *migrate.Migrate) error { return m.Force(version) }, nil
+	case "version":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("version takes no arguments")
+		}
+		return func(*migrate.Migrate) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("unknown command %q, run migrate -h for usage", command)
+	}
+}
+
+func intArg(command string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%s takes exactly one argument", command)
+	}
+
+	value, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s argument %q: %w", command, args[0], err)
+	}
+
+	return value, nil
+}
+
+func printVersion(m *migrate.Migrate, logger *slog.Logger) error {
+	latest, err := database.LatestMigration()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		logger.Info("Database schema version", "version", "none", "latest", latest)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	logger.Info("Database schema version", "version", version, "dirty", dirty, "latest", latest)
+	return nil
+}
+
+type migrationLogger struct {
+	logger *slog.Logger
+}
+
+func (l migrationLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l migrationLogger) Verbose() bool {
+	return false
+}
@@ -12,7 +12,9 @@ import (
 
 	"github.com/VxVxN/financialanalyzer/internal/application"
 	"github.com/VxVxN/financialanalyzer/internal/config"
+	"github.com/VxVxN/financialanalyzer/internal/database"
 	"github.com/VxVxN/financialanalyzer/internal/handlers"
+	"github.com/VxVxN/financialanalyzer/internal/parser"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -46,11 +48,18 @@ func run(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
 	}
 	defer app.Close()
 
-	if err = app.MigrateDB(); err != nil {
+	if err = app.CheckSchema(); err != nil {
 		return err
 	}
 
-	controller := handlers.NewController(app.Repo)
+	// The mapping may declare metrics beyond the built-in ones; they must be registered before serving them.
+	if _, err := parser.LoadMapping(cfg.MappingPath); err != nil {
+		return err
+	}
+
+	go purgeDeletedCompanies(ctx, app.Repo, cfg.DeleteRetention, logger)
+
+	controller := handlers.NewController(app.Repo, cfg.DefaultCurrency, cfg.DeleteRetention, logger)
 
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
@@ -62,10 +71,20 @@ func run(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
 
 	r.Get("/", controller.IndexHandler)
 	r.Get("/api/companies", controller.GetCompanies)
+	r.Get("/api/companies/details", controller.GetCompanyDetails)
 	r.Delete("/api/companies", controller.DeleteCompany)
+	r.Post("/api/companies/restore", controller.RestoreCompany)
+	r.Post("/api/companies/rename", controller.RenameCompany)
+	r.Post("/api/companies/merge", controller.MergeCompanies)
+	r.Get("/api/company-aliases", controller.GetCompanyAliases)
+	r.Post("/api/company-aliases", controller.SaveCompanyAlias)
+	r.Delete("/api/company-aliases", controller.DeleteCompanyAlias)
 	r.Get("/api/companies-with-categories", controller.GetCompaniesWithCategories)
 	r.Get("/api/categories", controller.GetCategories)
 	r.Get("/chart/{metric}", controller.ChartHandler)
+	r.Get("/api/metrics/{metric}", controller.GetMetric)
+	r.Get("/api/import-runs", controller.GetImportRuns)
+	r.Get("/api/financial-history", controller.GetFinancialHistory)
 
 	r.Get("/api/company-note", controller.GetCompanyNote)
 	r.Post("/api/company-note", controller.SaveCompanyNote)
@@ -91,3 +110,25 @@ func run(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
 
 	return srv.ListenAndServe()
 }
+
+const purgeInterval = time.Hour
+
+func purgeDeletedCompanies(ctx context.Context, repo database.CompaniesRepository, retention time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := repo.PurgeDeletedCompanies(ctx, retention)
+		if err != nil {
+			logger.Error("Failed to purge deleted companies", "error", err)
+		} else if purged > 0 {
+			logger.Info("Purged deleted companies", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
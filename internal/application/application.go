@@ -26,9 +26,9 @@ func Init(cfg *config.Config) (*Application, error) {
 	}, nil
 }
 
-func (app *Application) MigrateDB() error {
-	if err := database.RunMigrations(app.db); err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+func (app *Application) CheckSchema() error {
+	if err := database.CheckSchema(app.db); err != nil {
+		return fmt.Errorf("failed to check database schema: %w", err)
 	}
 	return nil
 }
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"runtime"
 	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -15,19 +17,33 @@ type Config struct {
 	DBName     string
 	DBSSLMode  string
 
-	CSVPath string
+	CSVPath      string
+	MappingPath  string
+	ParseWorkers int
+
+	FXRatesPath     string
+	DefaultCurrency string
+
+	DeleteRetention time.Duration
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		Port:       getEnvInt("PORT", 8080),
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "password"),
-		DBName:     getEnv("DB_NAME", "postgres"),
-		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
-		CSVPath:    getEnv("CSV_PATH", ""),
+		Port:         getEnvInt("PORT", 8080),
+		DBHost:       getEnv("DB_HOST", "localhost"),
+		DBPort:       getEnv("DB_PORT", "5432"),
+		DBUser:       getEnv("DB_USER", "postgres"),
+		DBPassword:   getEnv("DB_PASSWORD", "password"),
+		DBName:       getEnv("DB_NAME", "postgres"),
+		DBSSLMode:    getEnv("DB_SSLMODE", "disable"),
+		CSVPath:      getEnv("CSV_PATH", ""),
+		MappingPath:  getEnv("METRIC_MAPPING_PATH", ""),
+		ParseWorkers: getEnvInt("PARSE_WORKERS", runtime.NumCPU()),
+
+		FXRatesPath:     getEnv("FX_RATES_PATH", ""),
+		DefaultCurrency: getEnv("DEFAULT_CURRENCY", "RUB"),
+
+		DeleteRetention: getEnvDuration("DELETE_RETENTION", 7*24*time.Hour),
 	}
 }
 
@@ -48,3 +64,14 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		result, err := time.ParseDuration(value)
+		if err != nil {
+			return defaultValue
+		}
+		return result
+	}
+	return defaultValue
+}
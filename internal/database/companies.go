@@ -0,0 +1,264 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/models"
+	"github.com/lib/pq"
+)
+
+type CompanyWithCategory struct {
+	Company  string `json:"company"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+func (r *Repository) GetAllCompanies(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT ticker FROM companies WHERE deleted_at IS NULL ORDER BY ticker`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var companies []string
+	for rows.Next() {
+		var company string
+		if err := rows.Scan(&company); err != nil {
+			return nil, err
+		}
+		companies = append(companies, company)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return companies, nil
+}
+
+func (r *Repository) GetCompanies(ctx context.Context) ([]models.Company, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, ticker, name, category, currency, country, tags, created_at
+        FROM companies
+        WHERE deleted_at IS NULL
+        ORDER BY ticker
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("error getting companies: %w", err)
+	}
+	defer rows.Close()
+
+	var companies []models.Company
+	for rows.Next() {
+		var company models.Company
+		var currency, country sql.NullString
+		if err := rows.Scan(&company.ID, &company.Ticker, &company.Name, &company.Category, &currency, &country,
+			pq.Array(&company.Tags), &company.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning company: %w", err)
+		}
+		company.Currency = currency.String
+		company.Country = country.String
+		companies = append(companies, company)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return companies, nil
+}
+
+func (r *Repository) GetAllCategories(ctx context.Context) ([]string, error) {
+	query := `SELECT DISTINCT category FROM companies WHERE deleted_at IS NULL AND category IS NOT NULL AND category != '' ORDER BY category`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error getting categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []string
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return nil, fmt.Errorf("error scanning category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, nil
+}
+
+func (r *Repository) GetAllCompaniesWithCategories(ctx context.Context) ([]CompanyWithCategory, error) {
+	query := `SELECT ticker, name, category FROM companies WHERE deleted_at IS NULL ORDER BY ticker`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error getting companies with categories: %w", err)
+	}
+	defer rows.Close()
+
+	var companies []CompanyWithCategory
+	for rows.Next() {
+		var c CompanyWithCategory
+		if err := rows.Scan(&c.Company, &c.Name, &c.Category); err != nil {
+			return nil, fmt.Errorf("error scanning company with category: %w", err)
+		}
+		companies = append(companies, c)
+	}
+
+	return companies, nil
+}
+
+// DeleteCompany hides the company together with its financials, notes, colors and aliases, which are
+// only reachable through it. It can be restored until PurgeDeletedCompanies removes it for good.
+func (r *Repository) DeleteCompany(ctx context.Context, company string) (time.Time, error) {
+	query := `
+        UPDATE companies
+        SET deleted_at = CURRENT_TIMESTAMP
+        WHERE ticker = $1 AND deleted_at IS NULL
+        RETURNING deleted_at
+    `
+
+	var deletedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, company).Scan(&deletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, fmt.Errorf("company %s not found", company)
+		}
+		return time.Time{}, fmt.Errorf("error deleting company %s: %w", company, err)
+	}
+
+	return deletedAt, nil
+}
+
+func (r *Repository) RestoreCompany(ctx context.Context, company string, retention time.Duration) error {
+	query := `
+        UPDATE companies
+        SET deleted_at = NULL
+        WHERE ticker = $1 AND deleted_at IS NOT NULL
+          AND deleted_at > CURRENT_TIMESTAMP - $2 * INTERVAL '1 second'
+    `
+
+	result, err := r.db.ExecContext(ctx, query, company, retention.Seconds())
+	if err != nil {
+		return fmt.Errorf("error restoring company %s: %w", company, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted company %s not found or retention period expired", company)
+	}
+
+	return nil
+}
+
+// PurgeDeletedCompanies permanently removes companies deleted longer than retention ago;
+// everything attached to them goes with them through ON DELETE CASCADE.
+func (r *Repository) PurgeDeletedCompanies(ctx context.Context, retention time.Duration) (int64, error) {
+	query := `
+        DELETE FROM companies
+        WHERE deleted_at IS NOT NULL
+          AND deleted_at <= CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'
+    `
+
+	result, err := r.db.ExecContext(ctx, query, retention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("error purging deleted companies: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return purged, nil
+}
+
+// ensureCompanies upserts the given companies plus any ticker referenced by data and returns their ids by ticker.
+// Metadata already stored is only overwritten by non-empty values.
+func ensureCompanies(ctx context.Context, tx *sql.Tx, companies []models.Company, data []models.QuarterData) (map[string]int64, error) {
+	pending := make(map[string]models.Company)
+	order := make([]string, 0, len(companies))
+	for _, company := range companies {
+		if _, ok := pending[company.Ticker]; !ok {
+			order = append(order, company.Ticker)
+		}
+		pending[company.Ticker] = company
+	}
+	for _, item := range data {
+		if _, ok := pending[item.Company]; !ok {
+			order = append(order, item.Company)
+			pending[item.Company] = models.Company{Ticker: item.Company, Category: item.Category}
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO companies (ticker, name, category, currency, country, tags)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (ticker)
+        DO UPDATE SET
+            name = COALESCE(NULLIF(EXCLUDED.name, EXCLUDED.ticker), companies.name),
+            category = COALESCE(NULLIF(EXCLUDED.category, 'unknown'), companies.category),
+            currency = COALESCE(EXCLUDED.currency, companies.currency),
+            country = COALESCE(EXCLUDED.country, companies.country),
+            tags = CASE WHEN cardinality(EXCLUDED.tags) > 0 THEN EXCLUDED.tags ELSE companies.tags END
+        RETURNING id, deleted_at IS NOT NULL
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare company upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	ids := make(map[string]int64, len(order))
+	for _, ticker := range order {
+		company := pending[ticker]
+		name := company.Name
+		if name == "" {
+			name = company.Ticker
+		}
+		category := company.Category
+		if category == "" {
+			category = "unknown"
+		}
+		tags := company.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+
+		var id int64
+		var deleted bool
+		err := stmt.QueryRowContext(ctx, company.Ticker, name, category, nullableString(company.Currency),
+			nullableString(company.Country), pq.Array(tags)).Scan(&id, &deleted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save company %s: %w", company.Ticker, err)
+		}
+		if deleted {
+			return nil, fmt.Errorf("company %s is deleted; restore it or wait until it is purged", company.Ticker)
+		}
+		ids[ticker] = id
+	}
+
+	return ids, nil
+}
+
+func requireCompany(result sql.Result, company string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("company %s not found", company)
+	}
+
+	return nil
+}
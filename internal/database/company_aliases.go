@@ -0,0 +1,293 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type CompanyAlias struct {
+	Alias   string `json:"alias"`
+	Company string `json:"company"`
+}
+
+func (r *Repository) RenameCompany(ctx context.Context, company, newTicker string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := lockCompany(ctx, tx, company)
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM companies WHERE ticker = $1)`, newTicker).Scan(&exists); err != nil {
+		return fmt.Errorf("error checking company %s: %w", newTicker, err)
+	}
+	if exists {
+		return fmt.Errorf("company %s already exists", newTicker)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE companies
+        SET ticker = $2,
+            name = CASE WHEN name = ticker THEN $2 ELSE name END
+        WHERE id = $1
+    `, id, newTicker)
+	if err != nil {
+		return fmt.Errorf("error renaming company %s: %w", company, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM company_aliases WHERE alias = $1`, newTicker); err != nil {
+		return fmt.Errorf("error deleting alias %s: %w", newTicker, err)
+	}
+
+	if err := saveAlias(ctx, tx, company, id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// MergeCompanies moves everything from source into target and deletes source. Where both companies
+// have a value for the same period and metric, the target's value is kept.
+func (r *Repository) MergeCompanies(ctx context.Context, source, target string) error {
+	if source == target {
+		return fmt.Errorf("cannot merge company %s into itself", source)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	sourceID, err := lockCompany(ctx, tx, source)
+	if err != nil {
+		return err
+	}
+	targetID, err := lockCompany(ctx, tx, target)
+	if err != nil {
+		return err
+	}
+
+	if err := setChangeSource(ctx, tx, ChangeSourceMerge); err != nil {
+		return err
+	}
+
+	statements := []struct {
+		name  string
+		query string
+	}{
+		{"fill overlapping financials", `
+            UPDATE company_financials t
+            SET report_date = COALESCE(t.report_date, s.report_date),
+                currency = COALESCE(t.currency, s.currency)
+            FROM company_financials s
+            WHERE t.company_id = $2 AND s.company_id = $1
+              AND t.year = s.year AND t.quarter = s.quarter AND t.period_type = s.period_type
+        `},
+		{"fill overlapping values", `
+            INSERT INTO financial_values (financial_id, metric_key, value, import_run_id)
+            SELECT t.id, sv.metric_key, sv.value, sv.import_run_id
+            FROM company_financials s
+            JOIN company_financials t
+              ON t.year = s.year AND t.quarter = s.quarter AND t.period_type = s.period_type
+            JOIN financial_values sv ON sv.financial_id = s.id
+            WHERE s.company_id = $1 AND t.company_id = $2
+            ON CONFLICT (financial_id, metric_key) DO NOTHING
+        `},
+		{"drop overlapping financials", `
+            DELETE FROM company_financials s
+            USING company_financials t
+            WHERE s.company_id = $1 AND t.company_id = $2
+              AND t.year = s.year AND t.quarter = s.quarter AND t.period_type = s.period_type
+        `},
+		{"move financials", `UPDATE company_financials SET company_id = $2 WHERE company_id = $1`},
+		{"move financial history", `UPDATE financial_history SET company_id = $2 WHERE company_id = $1`},
+		{"merge notes", `
+            UPDATE company_notes t
+            SET note = CONCAT_WS(E'\n\n', NULLIF(t.note, ''), NULLIF(s.note, '')),
+                updated_at = CURRENT_TIMESTAMP
+            FROM company_notes s
+            WHERE t.company_id = $2 AND s.company_id = $1
+        `},
+		{"move notes", `
+            UPDATE company_notes SET company_id = $2
+            WHERE company_id = $1 AND NOT EXISTS (SELECT 1 FROM company_notes WHERE company_id = $2)
+        `},
+		{"move colors", `
+            UPDATE company_colors SET company_id = $2
+            WHERE company_id = $1 AND NOT EXISTS (SELECT 1 FROM company_colors WHERE company_id = $2)
+        `},
+		{"move aliases", `UPDATE company_aliases SET company_id = $2 WHERE company_id = $1`},
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement.query, sourceID, targetID); err != nil {
+			return fmt.Errorf("failed to %s: %w", statement.name, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM companies WHERE id = $1`, sourceID); err != nil {
+		return fmt.Errorf("error deleting company %s: %w", source, err)
+	}
+
+	if err := saveAlias(ctx, tx, source, targetID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetCompanyAliases(ctx context.Context, company string) ([]CompanyAlias, error) {
+	query := `
+        SELECT a.alias, c.ticker
+        FROM company_aliases a
+        JOIN companies c ON c.id = a.company_id
+        WHERE c.deleted_at IS NULL AND ($1 = '' OR c.ticker = $1)
+        ORDER BY c.ticker, a.alias
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, company)
+	if err != nil {
+		return nil, fmt.Errorf("error getting company aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := make([]CompanyAlias, 0)
+	for rows.Next() {
+		var alias CompanyAlias
+		if err := rows.Scan(&alias.Alias, &alias.Company); err != nil {
+			return nil, fmt.Errorf("error scanning company alias: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return aliases, nil
+}
+
+func (r *Repository) SaveCompanyAlias(ctx context.Context, alias, company string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM companies WHERE ticker = $1)`, alias).Scan(&exists); err != nil {
+		return fmt.Errorf("error checking company %s: %w", alias, err)
+	}
+	if exists {
+		return fmt.Errorf("company %s already exists", alias)
+	}
+
+	id, err := lockCompany(ctx, tx, company)
+	if err != nil {
+		return err
+	}
+
+	if err := saveAlias(ctx, tx, alias, id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteCompanyAlias(ctx context.Context, alias string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM company_aliases WHERE alias = $1`, alias)
+	if err != nil {
+		return fmt.Errorf("error deleting company alias: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("alias %s not found", alias)
+	}
+
+	return nil
+}
+
+// ResolveAliases maps every known alias among tickers to the current ticker of its company.
+func (r *Repository) ResolveAliases(ctx context.Context, tickers []string) (map[string]string, error) {
+	resolved := make(map[string]string)
+	if len(tickers) == 0 {
+		return resolved, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT a.alias, c.ticker
+        FROM company_aliases a
+        JOIN companies c ON c.id = a.company_id
+        WHERE a.alias = ANY($1) AND c.deleted_at IS NULL
+    `, pq.Array(tickers))
+	if err != nil {
+		return nil, fmt.Errorf("error resolving company aliases: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var alias, ticker string
+		if err := rows.Scan(&alias, &ticker); err != nil {
+			return nil, fmt.Errorf("error scanning company alias: %w", err)
+		}
+		resolved[alias] = ticker
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return resolved, nil
+}
+
+func lockCompany(ctx context.Context, tx *sql.Tx, company string) (int64, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM companies WHERE ticker = $1 AND deleted_at IS NULL FOR UPDATE`, company).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("company %s not found", company)
+		}
+		return 0, fmt.Errorf("error getting company %s: %w", company, err)
+	}
+	return id, nil
+}
+
+func saveAlias(ctx context.Context, tx *sql.Tx, alias string, companyID int64) error {
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO company_aliases (alias, company_id)
+        VALUES ($1, $2)
+        ON CONFLICT (alias)
+        DO UPDATE SET company_id = EXCLUDED.company_id
+    `, alias, companyID)
+	if err != nil {
+		return fmt.Errorf("error saving alias %s: %w", alias, err)
+	}
+	return nil
+}
@@ -0,0 +1,739 @@
+// Package dbtest is a conformance suite for database.Store implementations. Every implementation
+// runs the same cases, so the in-memory store can stand in for PostgreSQL in handler tests.
+package dbtest
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/fx"
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+// Run runs the suite; newStore must return an empty store for every call.
+func Run(t *testing.T, newStore func(t *testing.T) database.Store) {
+	cases := []struct {
+		name string
+		test func(t *testing.T, store database.Store)
+	}{
+		{"ImportCreatesCompanies", testImportCreatesCompanies},
+		{"ImportModes", testImportModes},
+		{"CompaniesMetric", testCompaniesMetric},
+		{"RegisteredMetric", testRegisteredMetric},
+		{"LatestLTM", testLatestLTM},
+		{"FinancialHistory", testFinancialHistory},
+		{"Notes", testNotes},
+		{"Colors", testColors},
+		{"DeleteRestorePurge", testDeleteRestorePurge},
+		{"ImportIntoDeletedCompany", testImportIntoDeletedCompany},
+		{"RenameAndAliases", testRenameAndAliases},
+		{"MergeCompanies", testMergeCompanies},
+		{"ImportRuns", testImportRuns},
+		{"FXRates", testFXRates},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.test(t, newStore(t))
+		})
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func quarter(company string, year int, label string, revenue float64) models.QuarterData {
+	return models.QuarterData{
+		Year:       year,
+		Quarter:    label,
+		PeriodType: models.PeriodQuarter,
+		Company:    company,
+		Currency:   "RUB",
+		Values:     map[string]models.NullFloat64{"revenue": models.Float(revenue)},
+	}
+}
+
+func startRun(t *testing.T, store database.Store) int64 {
+	t.Helper()
+	runID, err := store.StartImportRun(t.Context(), "testdata")
+	if err != nil {
+		t.Fatalf("StartImportRun: %v", err)
+	}
+	return runID
+}
+
+func save(t *testing.T, store database.Store, mode models.ImportMode, companies []models.Company, data ...models.QuarterData) int64 {
+	t.Helper()
+	runID := startRun(t, store)
+	if err := store.SaveQuarterDataBatch(t.Context(), runID, mode, companies, data); err != nil {
+		t.Fatalf("SaveQuarterDataBatch: %v", err)
+	}
+	return runID
+}
+
+func companies(t *testing.T, store database.Store) []string {
+	t.Helper()
+	result, err := store.GetAllCompanies(t.Context())
+	if err != nil {
+		t.Fatalf("GetAllCompanies: %v", err)
+	}
+	return result
+}
+
+func expectErrorContaining(t *testing.T, err error, substr string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected error containing %q, got nil", substr)
+	}
+	if !strings.Contains(err.Error(), substr) {
+		t.Fatalf("expected error containing %q, got %v", substr, err)
+	}
+}
+
+func testImportCreatesCompanies(t *testing.T, store database.Store) {
+	save(t, store, models.ImportModeMerge, []models.Company{{Ticker: "SBER", Name: "Sberbank", Category: "banks", Currency: "RUB", Tags: []string{"dividends"}}},
+		quarter("SBER", 2023, "Q1", 100))
+	save(t, store, models.ImportModeMerge, []models.Company{{Ticker: "SBER", Category: "unknown"}},
+		quarter("SBER", 2023, "Q2", 110))
+	save(t, store, models.ImportModeMerge, nil, quarter("GAZP", 2023, "Q1", 50))
+
+	got, err := store.GetCompanies(t.Context())
+	if err != nil {
+		t.Fatalf("GetCompanies: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 companies, got %d", len(got))
+	}
+
+	gazp, sber := got[0], got[1]
+	if gazp.Ticker != "GAZP" || gazp.Name != "GAZP" || gazp.Category != "unknown" {
+		t.Errorf("unexpected GAZP %+v", gazp)
+	}
+	if sber.Ticker != "SBER" || sber.Name != "Sberbank" || sber.Category != "banks" || sber.Currency != "RUB" {
+		t.Errorf("metadata of SBER was not kept: %+v", sber)
+	}
+	if !reflect.DeepEqual(sber.Tags, []string{"dividends"}) {
+		t.Errorf("expected tags [dividends], got %v", sber.Tags)
+	}
+
+	categories, err := store.GetAllCategories(t.Context())
+	if err != nil {
+		t.Fatalf("GetAllCategories: %v", err)
+	}
+	if !reflect.DeepEqual(categories, []string{"banks", "unknown"}) {
+		t.Errorf("unexpected categories %v", categories)
+	}
+
+	withCategories, err := store.GetAllCompaniesWithCategories(t.Context())
+	if err != nil {
+		t.Fatalf("GetAllCompaniesWithCategories: %v", err)
+	}
+	want := []database.CompanyWithCategory{
+		{Company: "GAZP", Name: "GAZP", Category: "unknown"},
+		{Company: "SBER", Name: "Sberbank", Category: "banks"},
+	}
+	if !reflect.DeepEqual(withCategories, want) {
+		t.Errorf("expected %+v, got %+v", want, withCategories)
+	}
+}
+
+func testImportModes(t *testing.T, store database.Store) {
+	first := quarter("SBER", 2023, "Q1", 100.123)
+	first.SetValue("net_profit", models.Float(10))
+	first.SetValue("pe", models.Float(5.5))
+	first.ReportDate = date(2023, time.April, 28)
+	save(t, store, models.ImportModeMerge, nil, first)
+
+	update := models.QuarterData{Year: 2023, Quarter: "Q1", PeriodType: models.PeriodQuarter, Company: "SBER",
+		Values: map[string]models.NullFloat64{"net_profit": models.Float(12), "pe": models.Cleared()}}
+	save(t, store, models.ImportModeMerge, nil, update)
+
+	data, err := store.GetQuarterData(t.Context(), []string{"SBER"})
+	if err != nil {
+		t.Fatalf("GetQuarterData: %v", err)
+	}
+	if len(data) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(data))
+	}
+	row := data[0]
+	if !row.Value("revenue").Valid || row.Value("revenue").Float64 != 100.12 {
+		t.Errorf("merge must keep revenue rounded to 100.12, got %+v", row.Value("revenue"))
+	}
+	if !row.Value("net_profit").Valid || row.Value("net_profit").Float64 != 12 {
+		t.Errorf("merge must overwrite net profit, got %+v", row.Value("net_profit"))
+	}
+	if row.Value("pe").Valid {
+		t.Errorf("cleared P/E must be removed, got %+v", row.Value("pe"))
+	}
+	if row.Currency != "RUB" || row.ReportDate.Format("2006-01-02") != "2023-04-28" {
+		t.Errorf("merge must keep currency and report date, got %q %s", row.Currency, row.ReportDate)
+	}
+
+	save(t, store, models.ImportModeReplace, nil, models.QuarterData{Year: 2023, Quarter: "Q1", PeriodType: models.PeriodQuarter,
+		Company: "SBER", Values: map[string]models.NullFloat64{"revenue": models.Float(200)}})
+
+	data, err = store.GetQuarterData(t.Context(), []string{"SBER"})
+	if err != nil {
+		t.Fatalf("GetQuarterData: %v", err)
+	}
+	row = data[0]
+	if !row.Value("revenue").Valid || row.Value("revenue").Float64 != 200 || row.Value("net_profit").Valid || row.Currency != "" || !row.ReportDate.IsZero() {
+		t.Errorf("replace must overwrite the whole row, got %+v", row)
+	}
+
+	empty, err := store.GetQuarterData(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("GetQuarterData: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("expected no rows for no companies, got %d", len(empty))
+	}
+}
+
+func testCompaniesMetric(t *testing.T, store database.Store) {
+	year := quarter("SBER", 2022, models.LabelFullYear, 400)
+	year.PeriodType = models.PeriodYear
+	save(t, store, models.ImportModeMerge, nil,
+		quarter("SBER", 2023, "Q2", 2),
+		quarter("SBER", 2023, "Q1", 1),
+		quarter("SBER", 2022, "Q4", 0.5),
+		quarter("GAZP", 2023, "Q1", 3),
+		models.QuarterData{Year: 2023, Quarter: "Q3", PeriodType: models.PeriodQuarter, Company: "SBER", Values: map[string]models.NullFloat64{"net_profit": models.Float(1)}},
+		year,
+	)
+
+	got, err := store.GetCompaniesMetric(t.Context(), []string{"SBER", "GAZP"}, "revenue", models.PeriodQuarter)
+	if err != nil {
+		t.Fatalf("GetCompaniesMetric: %v", err)
+	}
+
+	var periods []string
+	for _, item := range got {
+		periods = append(periods, item.Company+" "+models.Period{Year: item.Year, Label: item.Quarter}.String())
+	}
+	want := []string{"SBER 2022-Q4", "GAZP 2023-Q1", "SBER 2023-Q1", "SBER 2023-Q2"}
+	if !reflect.DeepEqual(periods, want) {
+		t.Errorf("expected %v, got %v", want, periods)
+	}
+
+	years, err := store.GetCompaniesMetric(t.Context(), []string{"SBER"}, "revenue", models.PeriodYear)
+	if err != nil {
+		t.Fatalf("GetCompaniesMetric: %v", err)
+	}
+	if len(years) != 1 || years[0].Quarter != models.LabelFullYear || years[0].Value != 400 {
+		t.Errorf("expected only the annual value, got %+v", years)
+	}
+
+	if _, err := store.GetCompaniesMetric(t.Context(), []string{"SBER"}, "unknown_metric", models.PeriodQuarter); err == nil {
+		t.Error("expected error for unknown metric")
+	}
+}
+
+func testRegisteredMetric(t *testing.T, store database.Store) {
+	const key = "dbtest_free_cash_flow"
+	if _, ok := metrics.Lookup(key); !ok {
+		if err := metrics.Register(metrics.Definition{Key: key, Unit: "млрд"}); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+
+	item := quarter("SBER", 2023, "Q1", 100)
+	item.SetValue(key, models.Float(42.5))
+	save(t, store, models.ImportModeMerge, nil, item)
+
+	got, err := store.GetCompaniesMetric(t.Context(), []string{"SBER"}, key, models.PeriodQuarter)
+	if err != nil {
+		t.Fatalf("GetCompaniesMetric: %v", err)
+	}
+	if len(got) != 1 || got[0].Value != 42.5 {
+		t.Errorf("expected the registered metric to be stored, got %+v", got)
+	}
+
+	save(t, store, models.ImportModeReplace, nil, quarter("SBER", 2023, "Q1", 100))
+
+	data, err := store.GetQuarterData(t.Context(), []string{"SBER"})
+	if err != nil {
+		t.Fatalf("GetQuarterData: %v", err)
+	}
+	if len(data) != 1 || data[0].Value(key).Valid || data[0].Value("revenue").Float64 != 100 {
+		t.Errorf("replace must drop the registered metric, got %+v", data)
+	}
+}
+
+func testLatestLTM(t *testing.T, store database.Store) {
+	ltm := func(company string, year int, label string, revenue float64) models.QuarterData {
+		item := quarter(company, year, label, revenue)
+		item.PeriodType = models.PeriodLTM
+		return item
+	}
+	save(t, store, models.ImportModeMerge, nil,
+		ltm("SBER", 2023, "Q3", 300),
+		ltm("SBER", 2023, "Q4", 400),
+		ltm("GAZP", 2022, "Q4", 100),
+		quarter("SBER", 2024, "Q1", 1),
+	)
+
+	got, err := store.GetLatestLTM(t.Context(), []string{"SBER", "GAZP"}, "revenue")
+	if err != nil {
+		t.Fatalf("GetLatestLTM: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 values, got %+v", got)
+	}
+	if got[0].Company != "GAZP" || got[0].Value != 100 {
+		t.Errorf("unexpected GAZP LTM %+v", got[0])
+	}
+	if got[1].Company != "SBER" || got[1].Year != 2023 || got[1].Quarter != "Q4" || got[1].Value != 400 {
+		t.Errorf("unexpected SBER LTM %+v", got[1])
+	}
+}
+
+func testFinancialHistory(t *testing.T, store database.Store) {
+	first := quarter("SBER", 2023, "Q1", 100)
+	first.SetValue("net_profit", models.Float(10))
+	firstRun := save(t, store, models.ImportModeMerge, nil, first, quarter("SBER", 2023, "Q2", 50))
+	secondRun := save(t, store, models.ImportModeMerge, nil, quarter("SBER", 2023, "Q1", 120))
+	save(t, store, models.ImportModeMerge, nil, quarter("SBER", 2023, "Q1", 120))
+	save(t, store, models.ImportModeMerge, nil, models.QuarterData{Year: 2023, Quarter: "Q1", PeriodType: models.PeriodQuarter,
+		Company: "SBER", Values: map[string]models.NullFloat64{"net_profit": models.Cleared()}})
+
+	changes, err := store.GetFinancialHistory(t.Context(), "SBER", 2023, "Q1", models.PeriodQuarter, "")
+	if err != nil {
+		t.Fatalf("GetFinancialHistory: %v", err)
+	}
+
+	type change struct {
+		metric   string
+		old, new string
+		runID    int64
+	}
+	format := func(value *float64) string {
+		if value == nil {
+			return "null"
+		}
+		return strconv.FormatFloat(*value, 'f', 2, 64)
+	}
+	var got []change
+	for _, item := range changes {
+		if item.Source != database.ChangeSourceImport || item.ImportRunID == nil || item.ChangedBy == "" || item.ChangedAt.IsZero() {
+			t.Errorf("unexpected change metadata %+v", item)
+			continue
+		}
+		got = append(got, change{item.Metric, format(item.OldValue), format(item.NewValue), *item.ImportRunID})
+	}
+	want := []change{
+		{"net_profit", "null", "10.00", firstRun},
+		{"net_profit", "10.00", "null", secondRun + 2},
+		{"revenue", "null", "100.00", firstRun},
+		{"revenue", "100.00", "120.00", secondRun},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected history %+v, got %+v", want, got)
+	}
+
+	revenue, err := store.GetFinancialHistory(t.Context(), "SBER", 2023, "Q1", models.PeriodQuarter, "revenue")
+	if err != nil {
+		t.Fatalf("GetFinancialHistory: %v", err)
+	}
+	if len(revenue) != 2 {
+		t.Errorf("expected 2 revenue changes, got %+v", revenue)
+	}
+
+	restatements, err := store.GetRestatements(t.Context(), []string{"SBER"}, "revenue", models.PeriodQuarter)
+	if err != nil {
+		t.Fatalf("GetRestatements: %v", err)
+	}
+	if len(restatements) != 1 || restatements[0].Quarter != "Q1" || restatements[0].Count != 1 || restatements[0].LastChangedAt.IsZero() {
+		t.Errorf("expected only 2023-Q1 to be restated once, got %+v", restatements)
+	}
+
+	save(t, store, models.ImportModeMerge, nil, models.QuarterData{Year: 2023, Quarter: "Q1", PeriodType: models.PeriodQuarter,
+		Company: "SBERP", Values: map[string]models.NullFloat64{"net_profit": models.Float(11)}})
+	if err := store.MergeCompanies(t.Context(), "SBERP", "SBER"); err != nil {
+		t.Fatalf("MergeCompanies: %v", err)
+	}
+
+	netProfit, err := store.GetFinancialHistory(t.Context(), "SBER", 2023, "Q1", models.PeriodQuarter, "net_profit")
+	if err != nil {
+		t.Fatalf("GetFinancialHistory: %v", err)
+	}
+	var sources []string
+	for _, item := range netProfit {
+		sources = append(sources, item.Source)
+	}
+	// The source company's own history moves along with its figures.
+	wantSources := []string{database.ChangeSourceImport, database.ChangeSourceImport, database.ChangeSourceImport, database.ChangeSourceMerge}
+	if !reflect.DeepEqual(sources, wantSources) {
+		t.Errorf("expected sources %v, got %v", wantSources, sources)
+	}
+}
+
+func testNotes(t *testing.T, store database.Store) {
+	expectErrorContaining(t, store.SaveCompanyNote(t.Context(), "SBER", "note"), "not found")
+
+	save(t, store, models.ImportModeMerge, nil, quarter("SBER", 2023, "Q1", 1))
+
+	note, err := store.GetCompanyNote(t.Context(), "SBER")
+	if err != nil || note != "" {
+		t.Fatalf("expected empty note, got %q, %v", note, err)
+	}
+
+	for _, text := range []string{"first", "second"} {
+		if err := store.SaveCompanyNote(t.Context(), "SBER", text); err != nil {
+			t.Fatalf("SaveCompanyNote: %v", err)
+		}
+	}
+	note, err = store.GetCompanyNote(t.Context(), "SBER")
+	if err != nil || note != "second" {
+		t.Fatalf("expected note %q, got %q, %v", "second", note, err)
+	}
+
+	if err := store.DeleteCompanyNote(t.Context(), "SBER"); err != nil {
+		t.Fatalf("DeleteCompanyNote: %v", err)
+	}
+	if note, _ := store.GetCompanyNote(t.Context(), "SBER"); note != "" {
+		t.Errorf("expected note to be deleted, got %q", note)
+	}
+	if err := store.DeleteCompanyNote(t.Context(), "MISSING"); err != nil {
+		t.Errorf("deleting a missing note must not fail: %v", err)
+	}
+}
+
+func testColors(t *testing.T, store database.Store) {
+	expectErrorContaining(t, store.SaveCompanyColor(t.Context(), "SBER", "#ff0000"), "not found")
+
+	save(t, store, models.ImportModeMerge, []models.Company{
+		{Ticker: "SBER", Category: "banks"},
+		{Ticker: "VTBR", Category: "banks"},
+		{Ticker: "GAZP", Category: "oil"},
+	}, quarter("SBER", 2023, "Q1", 1))
+
+	for company, color := range map[string]string{"SBER": "#ff0000", "GAZP": "#0000ff"} {
+		if err := store.SaveCompanyColor(t.Context(), company, color); err != nil {
+			t.Fatalf("SaveCompanyColor: %v", err)
+		}
+	}
+
+	colors, err := store.GetCompaniesColors(t.Context(), []string{"SBER", "VTBR"})
+	if err != nil {
+		t.Fatalf("GetCompaniesColors: %v", err)
+	}
+	if !reflect.DeepEqual(colors, map[string]string{"SBER": "#ff0000"}) {
+		t.Errorf("unexpected colors %v", colors)
+	}
+
+	colors, err = store.GetCompaniesColors(t.Context(), nil)
+	if err != nil || colors == nil || len(colors) != 0 {
+		t.Errorf("expected empty map for no companies, got %v, %v", colors, err)
+	}
+
+	byCategory, err := store.GetCompaniesColorsByCategory(t.Context(), "banks")
+	if err != nil {
+		t.Fatalf("GetCompaniesColorsByCategory: %v", err)
+	}
+	if !reflect.DeepEqual(byCategory, map[string]string{"SBER": "#ff0000"}) {
+		t.Errorf("unexpected colors by category %v", byCategory)
+	}
+
+	all, err := store.GetCompaniesColorsByCategory(t.Context(), "")
+	if err != nil {
+		t.Fatalf("GetCompaniesColorsByCategory: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("expected colors of all categories, got %v", all)
+	}
+
+	if err := store.DeleteCompanyColor(t.Context(), "SBER"); err != nil {
+		t.Fatalf("DeleteCompanyColor: %v", err)
+	}
+	if colors, _ := store.GetCompaniesColors(t.Context(), []string{"SBER"}); len(colors) != 0 {
+		t.Errorf("expected color to be deleted, got %v", colors)
+	}
+}
+
+func testDeleteRestorePurge(t *testing.T, store database.Store) {
+	save(t, store, models.ImportModeMerge, nil, quarter("SBER", 2023, "Q1", 1), quarter("GAZP", 2023, "Q1", 2))
+	if err := store.SaveCompanyNote(t.Context(), "SBER", "note"); err != nil {
+		t.Fatalf("SaveCompanyNote: %v", err)
+	}
+
+	if _, err := store.DeleteCompany(t.Context(), "MISSING"); err == nil {
+		t.Error("expected error deleting a missing company")
+	}
+
+	deletedAt, err := store.DeleteCompany(t.Context(), "SBER")
+	if err != nil {
+		t.Fatalf("DeleteCompany: %v", err)
+	}
+	if deletedAt.IsZero() {
+		t.Error("expected deletion time")
+	}
+
+	if got := companies(t, store); !reflect.DeepEqual(got, []string{"GAZP"}) {
+		t.Errorf("deleted company must be hidden, got %v", got)
+	}
+	if data, _ := store.GetQuarterData(t.Context(), []string{"SBER"}); len(data) != 0 {
+		t.Errorf("financials of a deleted company must be hidden, got %d rows", len(data))
+	}
+	expectErrorContaining(t, store.SaveCompanyNote(t.Context(), "SBER", "other"), "not found")
+
+	if err := store.RestoreCompany(t.Context(), "SBER", time.Hour); err != nil {
+		t.Fatalf("RestoreCompany: %v", err)
+	}
+	if note, _ := store.GetCompanyNote(t.Context(), "SBER"); note != "note" {
+		t.Errorf("restore must bring back the note, got %q", note)
+	}
+	expectErrorContaining(t, store.RestoreCompany(t.Context(), "SBER", time.Hour), "not found")
+
+	if _, err := store.DeleteCompany(t.Context(), "SBER"); err != nil {
+		t.Fatalf("DeleteCompany: %v", err)
+	}
+
+	purged, err := store.PurgeDeletedCompanies(t.Context(), time.Hour)
+	if err != nil || purged != 0 {
+		t.Fatalf("nothing is older than the retention, purged %d, %v", purged, err)
+	}
+
+	purged, err = store.PurgeDeletedCompanies(t.Context(), 0)
+	if err != nil || purged != 1 {
+		t.Fatalf("expected 1 purged company, got %d, %v", purged, err)
+	}
+	expectErrorContaining(t, store.RestoreCompany(t.Context(), "SBER", time.Hour), "not found")
+
+	// A purged ticker is free again.
+	save(t, store, models.ImportModeMerge, nil, quarter("SBER", 2024, "Q1", 3))
+	data, err := store.GetQuarterData(t.Context(), []string{"SBER"})
+	if err != nil {
+		t.Fatalf("GetQuarterData: %v", err)
+	}
+	if len(data) != 1 || data[0].Year != 2024 {
+		t.Errorf("expected only the new row, got %+v", data)
+	}
+	if note, _ := store.GetCompanyNote(t.Context(), "SBER"); note != "" {
+		t.Errorf("purge must remove the note, got %q", note)
+	}
+}
+
+func testImportIntoDeletedCompany(t *testing.T, store database.Store) {
+	save(t, store, models.ImportModeMerge, nil, quarter("SBER", 2023, "Q1", 1))
+	if _, err := store.DeleteCompany(t.Context(), "SBER"); err != nil {
+		t.Fatalf("DeleteCompany: %v", err)
+	}
+
+	err := store.SaveQuarterDataBatch(t.Context(), startRun(t, store), models.ImportModeMerge, nil,
+		[]models.QuarterData{quarter("GAZP", 2023, "Q1", 2), quarter("SBER", 2023, "Q2", 2)})
+	expectErrorContaining(t, err, "is deleted")
+
+	if got := companies(t, store); len(got) != 0 {
+		t.Errorf("failed import must not create companies, got %v", got)
+	}
+}
+
+func testRenameAndAliases(t *testing.T, store database.Store) {
+	save(t, store, models.ImportModeMerge, []models.Company{{Ticker: "YNDX", Category: "it"}}, quarter("YNDX", 2023, "Q1", 1))
+	save(t, store, models.ImportModeMerge, nil, quarter("SBER", 2023, "Q1", 1))
+
+	expectErrorContaining(t, store.RenameCompany(t.Context(), "MISSING", "NEW"), "not found")
+	expectErrorContaining(t, store.RenameCompany(t.Context(), "YNDX", "SBER"), "already exists")
+
+	if err := store.RenameCompany(t.Context(), "YNDX", "YDEX"); err != nil {
+		t.Fatalf("RenameCompany: %v", err)
+	}
+	if got := companies(t, store); !reflect.DeepEqual(got, []string{"SBER", "YDEX"}) {
+		t.Errorf("unexpected companies after rename %v", got)
+	}
+	if data, _ := store.GetQuarterData(t.Context(), []string{"YDEX"}); len(data) != 1 {
+		t.Errorf("financials must follow the rename, got %d rows", len(data))
+	}
+
+	expectErrorContaining(t, store.SaveCompanyAlias(t.Context(), "SBER", "YDEX"), "already exists")
+	expectErrorContaining(t, store.SaveCompanyAlias(t.Context(), "SBERP", "MISSING"), "not found")
+	if err := store.SaveCompanyAlias(t.Context(), "SBERP", "SBER"); err != nil {
+		t.Fatalf("SaveCompanyAlias: %v", err)
+	}
+
+	aliases, err := store.GetCompanyAliases(t.Context(), "")
+	if err != nil {
+		t.Fatalf("GetCompanyAliases: %v", err)
+	}
+	want := []database.CompanyAlias{{Alias: "SBERP", Company: "SBER"}, {Alias: "YNDX", Company: "YDEX"}}
+	if !reflect.DeepEqual(aliases, want) {
+		t.Errorf("expected aliases %+v, got %+v", want, aliases)
+	}
+
+	aliases, err = store.GetCompanyAliases(t.Context(), "GAZP")
+	if err != nil || aliases == nil || len(aliases) != 0 {
+		t.Errorf("expected empty aliases, got %+v, %v", aliases, err)
+	}
+
+	resolved, err := store.ResolveAliases(t.Context(), []string{"YNDX", "SBERP", "GAZP"})
+	if err != nil {
+		t.Fatalf("ResolveAliases: %v", err)
+	}
+	if !reflect.DeepEqual(resolved, map[string]string{"YNDX": "YDEX", "SBERP": "SBER"}) {
+		t.Errorf("unexpected resolved aliases %v", resolved)
+	}
+
+	// Renaming back turns the old alias into the ticker again.
+	if err := store.RenameCompany(t.Context(), "YDEX", "YNDX"); err != nil {
+		t.Fatalf("RenameCompany: %v", err)
+	}
+	aliases, _ = store.GetCompanyAliases(t.Context(), "YNDX")
+	if !reflect.DeepEqual(aliases, []database.CompanyAlias{{Alias: "YDEX", Company: "YNDX"}}) {
+		t.Errorf("unexpected aliases after renaming back %+v", aliases)
+	}
+
+	if err := store.DeleteCompanyAlias(t.Context(), "SBERP"); err != nil {
+		t.Fatalf("DeleteCompanyAlias: %v", err)
+	}
+	expectErrorContaining(t, store.DeleteCompanyAlias(t.Context(), "SBERP"), "not found")
+}
+
+func testMergeCompanies(t *testing.T, store database.Store) {
+	source := quarter("SBERP", 2023, "Q1", 100)
+	source.SetValue("net_profit", models.Float(10))
+	target := quarter("SBER", 2023, "Q1", 200)
+	save(t, store, models.ImportModeMerge, nil, source, quarter("SBERP", 2023, "Q2", 110), target)
+
+	for company, note := range map[string]string{"SBERP": "preferred", "SBER": "common"} {
+		if err := store.SaveCompanyNote(t.Context(), company, note); err != nil {
+			t.Fatalf("SaveCompanyNote: %v", err)
+		}
+	}
+	if err := store.SaveCompanyColor(t.Context(), "SBERP", "#00ff00"); err != nil {
+		t.Fatalf("SaveCompanyColor: %v", err)
+	}
+	if err := store.SaveCompanyAlias(t.Context(), "SBERPOLD", "SBERP"); err != nil {
+		t.Fatalf("SaveCompanyAlias: %v", err)
+	}
+
+	expectErrorContaining(t, store.MergeCompanies(t.Context(), "SBER", "SBER"), "into itself")
+	expectErrorContaining(t, store.MergeCompanies(t.Context(), "MISSING", "SBER"), "not found")
+
+	if err := store.MergeCompanies(t.Context(), "SBERP", "SBER"); err != nil {
+		t.Fatalf("MergeCompanies: %v", err)
+	}
+
+	if got := companies(t, store); !reflect.DeepEqual(got, []string{"SBER"}) {
+		t.Errorf("source must be removed, got %v", got)
+	}
+
+	data, err := store.GetQuarterData(t.Context(), []string{"SBER"})
+	if err != nil {
+		t.Fatalf("GetQuarterData: %v", err)
+	}
+	if len(data) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(data))
+	}
+	if data[0].Value("revenue").Float64 != 200 || !data[0].Value("net_profit").Valid || data[0].Value("net_profit").Float64 != 10 {
+		t.Errorf("target values must win and gaps be filled from the source, got %+v", data[0])
+	}
+	if data[1].Quarter != "Q2" || data[1].Value("revenue").Float64 != 110 {
+		t.Errorf("source-only rows must move to the target, got %+v", data[1])
+	}
+
+	if note, _ := store.GetCompanyNote(t.Context(), "SBER"); note != "common\n\npreferred" {
+		t.Errorf("notes must be concatenated, got %q", note)
+	}
+	if colors, _ := store.GetCompaniesColors(t.Context(), []string{"SBER"}); colors["SBER"] != "#00ff00" {
+		t.Errorf("color must move when the target has none, got %v", colors)
+	}
+
+	resolved, err := store.ResolveAliases(t.Context(), []string{"SBERP", "SBERPOLD"})
+	if err != nil {
+		t.Fatalf("ResolveAliases: %v", err)
+	}
+	if !reflect.DeepEqual(resolved, map[string]string{"SBERP": "SBER", "SBERPOLD": "SBER"}) {
+		t.Errorf("unexpected resolved aliases %v", resolved)
+	}
+}
+
+func testImportRuns(t *testing.T, store database.Store) {
+	first := startRun(t, store)
+	second := startRun(t, store)
+
+	files := []database.ImportFile{
+		{RunID: first, Path: "a.csv", SHA256: "a1", MappingSHA256: "m1", Status: database.ImportFileImported},
+		{RunID: first, Path: "b.csv", SHA256: "b1", MappingSHA256: "m1", Status: database.ImportFileImported},
+		{RunID: second, Path: "a.csv", SHA256: "a2", MappingSHA256: "m2", Status: database.ImportFileSkipped},
+		{RunID: second, Path: "b.csv", SHA256: "b2", MappingSHA256: "m2", Status: database.ImportFileFailed},
+	}
+	for _, file := range files {
+		if err := store.SaveImportFile(t.Context(), file); err != nil {
+			t.Fatalf("SaveImportFile: %v", err)
+		}
+	}
+
+	err := store.FinishImportRun(t.Context(), database.ImportRun{ID: second, Status: database.ImportRunFailed, FilesTotal: 2, FilesFailed: 1, Error: "boom"})
+	if err != nil {
+		t.Fatalf("FinishImportRun: %v", err)
+	}
+
+	runs, err := store.GetImportRuns(t.Context(), 10)
+	if err != nil {
+		t.Fatalf("GetImportRuns: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != second || runs[1].ID != first {
+		t.Fatalf("expected newest run first, got %+v", runs)
+	}
+	if runs[0].Status != database.ImportRunFailed || runs[0].Error != "boom" || runs[0].FinishedAt == nil || runs[0].FilesFailed != 1 {
+		t.Errorf("finished run not saved, got %+v", runs[0])
+	}
+	if runs[1].Status != database.ImportRunRunning || runs[1].FinishedAt != nil {
+		t.Errorf("unfinished run must stay running, got %+v", runs[1])
+	}
+
+	if limited, _ := store.GetImportRuns(t.Context(), 1); len(limited) != 1 {
+		t.Errorf("expected limit to apply, got %d runs", len(limited))
+	}
+
+	checksums, err := store.GetImportedChecksums(t.Context())
+	if err != nil {
+		t.Fatalf("GetImportedChecksums: %v", err)
+	}
+	want := map[string]models.FileChecksum{
+		"a.csv": {SHA256: "a2", MappingSHA256: "m2"},
+		"b.csv": {SHA256: "b1", MappingSHA256: "m1"},
+	}
+	if !reflect.DeepEqual(checksums, want) {
+		t.Errorf("unexpected checksums %v", checksums)
+	}
+}
+
+func testFXRates(t *testing.T, store database.Store) {
+	rates := []fx.Rate{
+		{Currency: "USD", Date: date(2023, time.March, 31), Rate: 77.0863},
+		{Currency: "USD", Date: date(2023, time.January, 31), Rate: 69.5},
+		{Currency: "CNY", Date: date(2023, time.March, 31), Rate: 11.2},
+	}
+	if err := store.SaveFXRates(t.Context(), rates); err != nil {
+		t.Fatalf("SaveFXRates: %v", err)
+	}
+	if err := store.SaveFXRates(t.Context(), []fx.Rate{{Currency: "USD", Date: date(2023, time.March, 31), Rate: 78}}); err != nil {
+		t.Fatalf("SaveFXRates: %v", err)
+	}
+
+	got, err := store.GetFXRates(t.Context(), []string{"USD"})
+	if err != nil {
+		t.Fatalf("GetFXRates: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 rates, got %+v", got)
+	}
+	if got[0].Date.Format("2006-01-02") != "2023-01-31" || got[0].Rate != 69.5 {
+		t.Errorf("unexpected first rate %+v", got[0])
+	}
+	if got[1].Date.Format("2006-01-02") != "2023-03-31" || got[1].Rate != 78 {
+		t.Errorf("rate must be upserted, got %+v", got[1])
+	}
+
+	if none, _ := store.GetFXRates(t.Context(), nil); len(none) != 0 {
+		t.Errorf("expected no rates for no currencies, got %+v", none)
+	}
+}
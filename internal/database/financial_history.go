@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+)
+
+// Change sources recorded in financial_history. Writes without a source, e.g. manual SQL, are recorded as "manual".
+const (
+	ChangeSourceImport = "import"
+	ChangeSourceMerge  = "merge"
+	ChangeSourceManual = "manual"
+)
+
+type FinancialChange struct {
+	Metric      string    `json:"metric"`
+	OldValue    *float64  `json:"old_value"`
+	NewValue    *float64  `json:"new_value"`
+	Source      string    `json:"source"`
+	ImportRunID *int64    `json:"import_run_id,omitempty"`
+	ChangedBy   string    `json:"changed_by"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+// Restatement summarises the changes of a figure that overwrote or cleared a previously stored value.
+type Restatement struct {
+	Company       string
+	Year          int
+	Quarter       string
+	Count         int
+	LastChangedAt time.Time
+}
+
+func (r *Repository) GetFinancialHistory(ctx context.Context, company string, year int, quarter, periodType, metric string) ([]FinancialChange, error) {
+	query := `
+        SELECT h.metric, h.old_value, h.new_value, h.source, h.import_run_id, h.changed_by, h.changed_at
+        FROM financial_history h
+        JOIN companies c ON c.id = h.company_id
+        WHERE c.ticker = $1 AND c.deleted_at IS NULL
+          AND h.year = $2 AND h.quarter = $3 AND h.period_type = $4
+          AND ($5 = '' OR h.metric = $5)
+        ORDER BY h.metric, h.changed_at, h.id
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, company, year, quarter, periodType, metric)
+	if err != nil {
+		return nil, fmt.Errorf("error getting financial history: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]FinancialChange, 0)
+	for rows.Next() {
+		var change FinancialChange
+		var oldValue, newValue sql.NullFloat64
+		var importRunID sql.NullInt64
+		err := rows.Scan(&change.Metric, &oldValue, &newValue, &change.Source, &importRunID, &change.ChangedBy, &change.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning financial change: %w", err)
+		}
+
+		if oldValue.Valid {
+			change.OldValue = &oldValue.Float64
+		}
+		if newValue.Valid {
+			change.NewValue = &newValue.Float64
+		}
+		if importRunID.Valid {
+			change.ImportRunID = &importRunID.Int64
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (r *Repository) GetRestatements(ctx context.Context, companies []string, metric string, periodType string) ([]Restatement, error) {
+	def, ok := metrics.Lookup(metric)
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %s", metric)
+	}
+
+	if len(companies) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(companies))
+	args := make([]interface{}, len(companies))
+	for i, company := range companies {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = company
+	}
+	args = append(args, def.Key, periodType)
+
+	query := fmt.Sprintf(`
+        SELECT c.ticker, h.year, h.quarter, COUNT(*), MAX(h.changed_at)
+        FROM financial_history h
+        JOIN companies c ON c.id = h.company_id
+        WHERE c.ticker IN (%s) AND c.deleted_at IS NULL
+          AND h.metric = $%d AND h.period_type = $%d AND h.old_value IS NOT NULL
+        GROUP BY c.ticker, h.year, h.quarter
+        ORDER BY c.ticker, h.year, h.quarter
+    `, strings.Join(placeholders, ","), len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting restatements: %w", err)
+	}
+	defer rows.Close()
+
+	var restatements []Restatement
+	for rows.Next() {
+		var restatement Restatement
+		err := rows.Scan(&restatement.Company, &restatement.Year, &restatement.Quarter, &restatement.Count, &restatement.LastChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning restatement: %w", err)
+		}
+		restatements = append(restatements, restatement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return restatements, nil
+}
+
+// setChangeSource tells the financial_values history trigger who is writing for the rest of tx.
+func setChangeSource(ctx context.Context, tx *sql.Tx, source string) error {
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('app.change_source', $1, true)`, source); err != nil {
+		return fmt.Errorf("failed to set change source: %w", err)
+	}
+	return nil
+}
+
+// setImportRun tells the history trigger which run removes values for the rest of tx; written values
+// carry their run themselves.
+func setImportRun(ctx context.Context, tx *sql.Tx, runID int64) error {
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('app.import_run_id', $1, true)`, strconv.FormatInt(runID, 10)); err != nil {
+		return fmt.Errorf("failed to set import run: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/VxVxN/financialanalyzer/internal/fx"
+)
+
+func (r *Repository) SaveFXRates(ctx context.Context, rates []fx.Rate) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO fx_rates (currency, rate_date, rate)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (currency, rate_date)
+        DO UPDATE SET rate = EXCLUDED.rate
+    `)
+	if err != nil {
+		return fmt.Errorf("failed to prepare fx rate upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, rate.Currency, rate.Date, rate.Rate); err != nil {
+			return fmt.Errorf("error saving fx rate %s %s: %w", rate.Currency, rate.Date.Format("2006-01-02"), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetFXRates(ctx context.Context, currencies []string) ([]fx.Rate, error) {
+	if len(currencies) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(currencies))
+	args := make([]interface{}, len(currencies))
+	for i, currency := range currencies {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = currency
+	}
+
+	query := fmt.Sprintf(`
+        SELECT currency, rate_date, rate
+        FROM fx_rates
+        WHERE currency IN (%s)
+        ORDER BY currency, rate_date
+    `, strings.Join(placeholders, ","))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting fx rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []fx.Rate
+	for rows.Next() {
+		var rate fx.Rate
+		if err := rows.Scan(&rate.Currency, &rate.Date, &rate.Rate); err != nil {
+			return nil, fmt.Errorf("error scanning fx rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return rates, nil
+}
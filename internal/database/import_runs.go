@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+const (
+	ImportRunRunning   = "running"
+	ImportRunCompleted = "completed"
+	ImportRunFailed    = "failed"
+	// ImportRunPartial is a finished run in which some files were saved and others failed.
+	ImportRunPartial = "partial"
+
+	ImportFileImported = "imported"
+	ImportFileFailed   = "failed"
+	ImportFileSkipped  = "skipped"
+)
+
+type ImportRun struct {
+	ID           int64      `json:"id"`
+	SourcePath   string     `json:"source_path"`
+	Status       string     `json:"status"`
+	FilesTotal   int        `json:"files_total"`
+	FilesFailed  int        `json:"files_failed"`
+	FilesSkipped int        `json:"files_skipped"`
+	RowsParsed   int        `json:"rows_parsed"`
+	RowsWritten  int        `json:"rows_written"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+type ImportFile struct {
+	RunID         int64  `json:"run_id"`
+	Path          string `json:"path"`
+	SHA256        string `json:"sha256"`
+	MappingSHA256 string `json:"mapping_sha256"`
+	Size          int64  `json:"size"`
+	RowsParsed    int    `json:"rows_parsed"`
+	Errors        int    `json:"errors"`
+	Status        string `json:"status"`
+}
+
+func (r *Repository) StartImportRun(ctx context.Context, sourcePath string) (int64, error) {
+	query := `INSERT INTO import_runs (source_path, status) VALUES ($1, $2) RETURNING id`
+
+	var id int64
+	if err := r.db.QueryRowContext(ctx, query, sourcePath, ImportRunRunning).Scan(&id); err != nil {
+		return 0, fmt.Errorf("error starting import run: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) FinishImportRun(ctx context.Context, run ImportRun) error {
+	query := `
+        UPDATE import_runs
+        SET status = $2,
+            files_total = $3,
+            files_failed = $4,
+            files_skipped = $5,
+            rows_parsed = $6,
+            rows_written = $7,
+            error = NULLIF($8, ''),
+            finished_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `
+
+	_, err := r.db.ExecContext(ctx, query, run.ID, run.Status, run.FilesTotal, run.FilesFailed, run.FilesSkipped, run.RowsParsed, run.RowsWritten, run.Error)
+	if err != nil {
+		return fmt.Errorf("error finishing import run %d: %w", run.ID, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) SaveImportFile(ctx context.Context, file ImportFile) error {
+	query := `
+        INSERT INTO import_files (run_id, path, sha256, mapping_sha256, size, rows_parsed, errors, status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+
+	_, err := r.db.ExecContext(ctx, query, file.RunID, file.Path, file.SHA256, file.MappingSHA256, file.Size, file.RowsParsed, file.Errors, file.Status)
+	if err != nil {
+		return fmt.Errorf("error saving import file %s: %w", file.Path, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetImportRuns(ctx context.Context, limit int) ([]ImportRun, error) {
+	query := `
+        SELECT id, source_path, status, files_total, files_failed, files_skipped, rows_parsed, rows_written, error, started_at, finished_at
+        FROM import_runs
+        ORDER BY started_at DESC, id DESC
+        LIMIT $1
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting import runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]ImportRun, 0)
+	for rows.Next() {
+		var run ImportRun
+		var runError sql.NullString
+		var finishedAt sql.NullTime
+
+		err := rows.Scan(&run.ID, &run.SourcePath, &run.Status, &run.FilesTotal, &run.FilesFailed, &run.FilesSkipped,
+			&run.RowsParsed, &run.RowsWritten, &runError, &run.StartedAt, &finishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning import run: %w", err)
+		}
+
+		run.Error = runError.String
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (r *Repository) GetImportedChecksums(ctx context.Context) (map[string]models.FileChecksum, error) {
+	query := `
+        SELECT DISTINCT ON (path) path, sha256, mapping_sha256
+        FROM import_files
+        WHERE status IN ($1, $2)
+        ORDER BY path, id DESC
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, ImportFileImported, ImportFileSkipped)
+	if err != nil {
+		return nil, fmt.Errorf("error getting imported checksums: %w", err)
+	}
+	defer rows.Close()
+
+	checksums := make(map[string]models.FileChecksum)
+	for rows.Next() {
+		var path string
+		var checksum models.FileChecksum
+		if err := rows.Scan(&path, &checksum.SHA256, &checksum.MappingSHA256); err != nil {
+			return nil, fmt.Errorf("error scanning checksum: %w", err)
+		}
+		checksums[path] = checksum
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return checksums, nil
+}
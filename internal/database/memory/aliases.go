@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+)
+
+func (s *Store) GetCompanyAliases(ctx context.Context, company string) ([]database.CompanyAlias, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	aliases := make([]database.CompanyAlias, 0)
+	for alias, id := range s.aliases {
+		c := s.companies[id]
+		if c.deletedAt != nil || (company != "" && c.Ticker != company) {
+			continue
+		}
+		aliases = append(aliases, database.CompanyAlias{Alias: alias, Company: c.Ticker})
+	}
+
+	sort.Slice(aliases, func(i, j int) bool {
+		if aliases[i].Company != aliases[j].Company {
+			return aliases[i].Company < aliases[j].Company
+		}
+		return aliases[i].Alias < aliases[j].Alias
+	})
+
+	return aliases, nil
+}
+
+func (s *Store) SaveCompanyAlias(ctx context.Context, alias, company string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.companyByTicker(alias, true) != nil {
+		return fmt.Errorf("company %s already exists", alias)
+	}
+
+	c := s.companyByTicker(company, false)
+	if c == nil {
+		return fmt.Errorf("company %s not found", company)
+	}
+
+	s.aliases[alias] = c.ID
+
+	return nil
+}
+
+func (s *Store) DeleteCompanyAlias(ctx context.Context, alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.aliases[alias]; !ok {
+		return fmt.Errorf("alias %s not found", alias)
+	}
+	delete(s.aliases, alias)
+
+	return nil
+}
+
+func (s *Store) ResolveAliases(ctx context.Context, tickers []string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resolved := make(map[string]string)
+	for _, ticker := range tickers {
+		id, ok := s.aliases[ticker]
+		if !ok {
+			continue
+		}
+		if c := s.companies[id]; c.deletedAt == nil {
+			resolved[ticker] = c.Ticker
+		}
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,319 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+func (s *Store) GetAllCompanies(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var companies []string
+	for _, c := range s.activeCompanies() {
+		companies = append(companies, c.Ticker)
+	}
+
+	return companies, nil
+}
+
+func (s *Store) GetCompanies(ctx context.Context) ([]models.Company, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var companies []models.Company
+	for _, c := range s.activeCompanies() {
+		info := c.Company
+		info.Tags = append([]string{}, c.Tags...)
+		companies = append(companies, info)
+	}
+
+	return companies, nil
+}
+
+func (s *Store) GetAllCategories(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var categories []string
+	for _, c := range s.activeCompanies() {
+		if c.Category != "" && !seen[c.Category] {
+			seen[c.Category] = true
+			categories = append(categories, c.Category)
+		}
+	}
+	sort.Strings(categories)
+
+	return categories, nil
+}
+
+func (s *Store) GetAllCompaniesWithCategories(ctx context.Context) ([]database.CompanyWithCategory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var companies []database.CompanyWithCategory
+	for _, c := range s.activeCompanies() {
+		companies = append(companies, database.CompanyWithCategory{Company: c.Ticker, Name: c.Name, Category: c.Category})
+	}
+
+	return companies, nil
+}
+
+func (s *Store) DeleteCompany(ctx context.Context, company string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.companyByTicker(company, false)
+	if c == nil {
+		return time.Time{}, fmt.Errorf("company %s not found", company)
+	}
+
+	deletedAt := s.now()
+	c.deletedAt = &deletedAt
+
+	return deletedAt, nil
+}
+
+func (s *Store) RestoreCompany(ctx context.Context, company string, retention time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-retention)
+	for _, c := range s.companies {
+		if c.Ticker == company && c.deletedAt != nil && c.deletedAt.After(cutoff) {
+			c.deletedAt = nil
+			return nil
+		}
+	}
+
+	return fmt.Errorf("deleted company %s not found or retention period expired", company)
+}
+
+func (s *Store) PurgeDeletedCompanies(ctx context.Context, retention time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-retention)
+	var purged int64
+	for id, c := range s.companies {
+		if c.deletedAt != nil && !c.deletedAt.After(cutoff) {
+			s.removeCompany(id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (s *Store) RenameCompany(ctx context.Context, company, newTicker string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.companyByTicker(company, false)
+	if c == nil {
+		return fmt.Errorf("company %s not found", company)
+	}
+	if s.companyByTicker(newTicker, true) != nil {
+		return fmt.Errorf("company %s already exists", newTicker)
+	}
+
+	if c.Name == c.Ticker {
+		c.Name = newTicker
+	}
+	c.Ticker = newTicker
+	delete(s.aliases, newTicker)
+	s.aliases[company] = c.ID
+
+	return nil
+}
+
+func (s *Store) MergeCompanies(ctx context.Context, source, target string) error {
+	if source == target {
+		return fmt.Errorf("cannot merge company %s into itself", source)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sourceCompany := s.companyByTicker(source, false)
+	if sourceCompany == nil {
+		return fmt.Errorf("company %s not found", source)
+	}
+	targetCompany := s.companyByTicker(target, false)
+	if targetCompany == nil {
+		return fmt.Errorf("company %s not found", target)
+	}
+	sourceID, targetID := sourceCompany.ID, targetCompany.ID
+
+	for key, row := range s.financials {
+		if key.companyID != sourceID {
+			continue
+		}
+		delete(s.financials, key)
+
+		targetKey := key
+		targetKey.companyID = targetID
+		existing, ok := s.financials[targetKey]
+		if !ok {
+			s.financials[targetKey] = row
+			continue
+		}
+
+		if existing.data.ReportDate.IsZero() {
+			existing.data.ReportDate = row.data.ReportDate
+		}
+		if existing.data.Currency == "" {
+			existing.data.Currency = row.data.Currency
+		}
+		for _, metric := range metricKeys(row.data.Values) {
+			if !existing.data.Value(metric).Valid {
+				existing.data.SetValue(metric, row.data.Value(metric))
+				s.recordChange(targetKey, metric, models.NullFloat64{}, row.data.Value(metric), database.ChangeSourceMerge, row.runID)
+			}
+		}
+	}
+
+	for i := range s.history {
+		if s.history[i].key.companyID == sourceID {
+			s.history[i].key.companyID = targetID
+		}
+	}
+
+	if note, ok := s.notes[sourceID]; ok {
+		if targetNote, ok := s.notes[targetID]; ok {
+			var parts []string
+			for _, part := range []string{targetNote, note} {
+				if part != "" {
+					parts = append(parts, part)
+				}
+			}
+			s.notes[targetID] = strings.Join(parts, "\n\n")
+		} else {
+			s.notes[targetID] = note
+		}
+	}
+
+	if color, ok := s.colors[sourceID]; ok {
+		if _, ok := s.colors[targetID]; !ok {
+			s.colors[targetID] = color
+		}
+	}
+
+	for alias, id := range s.aliases {
+		if id == sourceID {
+			s.aliases[alias] = targetID
+		}
+	}
+
+	s.removeCompany(sourceID)
+	s.aliases[source] = targetID
+
+	return nil
+}
+
+// ensureCompanies mirrors the company upsert of the PostgreSQL repository; it must be called with s.mu held.
+func (s *Store) ensureCompanies(companies []models.Company, data []models.QuarterData) (map[string]int64, error) {
+	pending := make(map[string]models.Company)
+	order := make([]string, 0, len(companies))
+	for _, info := range companies {
+		if _, ok := pending[info.Ticker]; !ok {
+			order = append(order, info.Ticker)
+		}
+		pending[info.Ticker] = info
+	}
+	for _, item := range data {
+		if _, ok := pending[item.Company]; !ok {
+			order = append(order, item.Company)
+			pending[item.Company] = models.Company{Ticker: item.Company, Category: item.Category}
+		}
+	}
+
+	for _, ticker := range order {
+		if c := s.companyByTicker(ticker, true); c != nil && c.deletedAt != nil {
+			return nil, fmt.Errorf("company %s is deleted; restore it or wait until it is purged", ticker)
+		}
+	}
+
+	ids := make(map[string]int64, len(order))
+	for _, ticker := range order {
+		info := pending[ticker]
+
+		c := s.companyByTicker(ticker, false)
+		if c == nil {
+			s.nextCompanyID++
+			c = &company{Company: models.Company{
+				ID:        s.nextCompanyID,
+				Ticker:    ticker,
+				Name:      ticker,
+				Category:  "unknown",
+				Tags:      []string{},
+				CreatedAt: s.now(),
+			}}
+			s.companies[c.ID] = c
+		}
+
+		if info.Name != "" && info.Name != ticker {
+			c.Name = info.Name
+		}
+		if info.Category != "" && info.Category != "unknown" {
+			c.Category = info.Category
+		}
+		if info.Currency != "" {
+			c.Currency = info.Currency
+		}
+		if info.Country != "" {
+			c.Country = info.Country
+		}
+		if len(info.Tags) > 0 {
+			c.Tags = append([]string{}, info.Tags...)
+		}
+
+		ids[ticker] = c.ID
+	}
+
+	return ids, nil
+}
+
+// activeCompanies returns the companies that are not deleted, ordered by ticker; it must be called with s.mu held.
+func (s *Store) activeCompanies() []*company {
+	var companies []*company
+	for _, c := range s.companies {
+		if c.deletedAt == nil {
+			companies = append(companies, c)
+		}
+	}
+	sort.Slice(companies, func(i, j int) bool { return companies[i].Ticker < companies[j].Ticker })
+	return companies
+}
+
+// removeCompany deletes a company with everything attached to it; it must be called with s.mu held.
+func (s *Store) removeCompany(id int64) {
+	delete(s.companies, id)
+	delete(s.notes, id)
+	delete(s.colors, id)
+	for key := range s.financials {
+		if key.companyID == id {
+			delete(s.financials, key)
+		}
+	}
+	for alias, companyID := range s.aliases {
+		if companyID == id {
+			delete(s.aliases, alias)
+		}
+	}
+
+	history := s.history[:0]
+	for _, entry := range s.history {
+		if entry.key.companyID != id {
+			history = append(history, entry)
+		}
+	}
+	s.history = history
+}
@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+func (s *Store) SaveQuarterDataBatch(ctx context.Context, runID int64, mode models.ImportMode, companies []models.Company, data []models.QuarterData) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	companyIDs, err := s.ensureCompanies(companies, data)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range data {
+		periodType := item.PeriodType
+		if periodType == "" {
+			periodType = models.PeriodQuarter
+		}
+		key := financialKey{companyID: companyIDs[item.Company], year: item.Year, quarter: item.Quarter, periodType: periodType}
+
+		rowMode := mode
+		row, ok := s.financials[key]
+		if !ok {
+			row = &financialRow{data: models.QuarterData{Year: item.Year, Quarter: item.Quarter, PeriodType: periodType}}
+			s.financials[key] = row
+			rowMode = models.ImportModeReplace
+		}
+
+		reportDate, currency := row.data.ReportDate, row.data.Currency
+		if rowMode == models.ImportModeReplace || !item.ReportDate.IsZero() {
+			reportDate = truncateToDate(item.ReportDate)
+		}
+		if rowMode == models.ImportModeReplace || item.Currency != "" {
+			currency = item.Currency
+		}
+		if !ok || !reportDate.Equal(row.data.ReportDate) || currency != row.data.Currency {
+			row.runID = runID
+		}
+		row.data.ReportDate, row.data.Currency = reportDate, currency
+
+		for _, metric := range metricKeys(item.Values, row.data.Values) {
+			value := item.Value(metric)
+			previous := row.data.Value(metric)
+			switch {
+			case value.Valid:
+				row.data.SetValue(metric, models.Float(roundTo(value.Float64, 2)))
+			case value.Cleared || rowMode == models.ImportModeReplace:
+				delete(row.data.Values, metric)
+			}
+			s.recordChange(key, metric, previous, row.data.Value(metric), database.ChangeSourceImport, runID)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) GetQuarterData(ctx context.Context, companies []string) ([]models.QuarterData, error) {
+	if len(companies) == 0 {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(companies))
+	for _, company := range companies {
+		wanted[company] = true
+	}
+
+	var result []models.QuarterData
+	for key, row := range s.financials {
+		c := s.companies[key.companyID]
+		if c.deletedAt != nil || !wanted[c.Ticker] {
+			continue
+		}
+
+		item := row.data
+		item.Values = maps.Clone(row.data.Values)
+		item.Company = c.Ticker
+		item.Category = c.Category
+		result = append(result, item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Company != b.Company {
+			return a.Company < b.Company
+		}
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		if a.Quarter != b.Quarter {
+			return models.PeriodRank(a.Quarter) < models.PeriodRank(b.Quarter)
+		}
+		return a.PeriodType < b.PeriodType
+	})
+
+	return result, nil
+}
+
+func (s *Store) GetCompaniesMetric(ctx context.Context, companies []string, metric string, periodType string) ([]models.CompanyMetric, error) {
+	def, ok := metrics.Lookup(metric)
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %s", metric)
+	}
+
+	if len(companies) == 0 {
+		return nil, nil
+	}
+
+	result := s.companyMetrics(companies, def, periodType)
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		return models.PeriodRank(a.Quarter) < models.PeriodRank(b.Quarter)
+	})
+
+	return result, nil
+}
+
+func (s *Store) GetLatestLTM(ctx context.Context, companies []string, metric string) ([]models.CompanyMetric, error) {
+	def, ok := metrics.Lookup(metric)
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %s", metric)
+	}
+
+	if len(companies) == 0 {
+		return nil, nil
+	}
+
+	latest := make(map[string]models.CompanyMetric)
+	for _, item := range s.companyMetrics(companies, def, models.PeriodLTM) {
+		current, ok := latest[item.Company]
+		if !ok || current.Year < item.Year ||
+			(current.Year == item.Year && models.PeriodRank(current.Quarter) < models.PeriodRank(item.Quarter)) {
+			latest[item.Company] = item
+		}
+	}
+
+	result := make([]models.CompanyMetric, 0, len(latest))
+	for _, item := range latest {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Company < result[j].Company })
+
+	return result, nil
+}
+
+// companyMetrics returns the non-null values of def for the given companies and period type,
+// ordered by company so that ties keep a stable order.
+func (s *Store) companyMetrics(companies []string, def metrics.Definition, periodType string) []models.CompanyMetric {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(companies))
+	for _, company := range companies {
+		wanted[company] = true
+	}
+
+	var result []models.CompanyMetric
+	for key, row := range s.financials {
+		c := s.companies[key.companyID]
+		if c.deletedAt != nil || !wanted[c.Ticker] || key.periodType != periodType {
+			continue
+		}
+
+		data := row.data
+		value := data.Value(def.Key)
+		if !value.Valid {
+			continue
+		}
+
+		item := models.CompanyMetric{
+			Year:     data.Year,
+			Quarter:  data.Quarter,
+			Company:  c.Ticker,
+			Value:    value.Float64,
+			Currency: data.Currency,
+		}
+		if !data.ReportDate.IsZero() {
+			reportDate := data.ReportDate
+			item.ReportDate = &reportDate
+		}
+		result = append(result, item)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Company < result[j].Company })
+
+	return result
+}
+
+// metricKeys returns the metrics set in any of values in a stable order.
+func metricKeys(values ...map[string]models.NullFloat64) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, set := range values {
+		for key := range set {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
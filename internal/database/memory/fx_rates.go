@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/VxVxN/financialanalyzer/internal/fx"
+)
+
+func (s *Store) SaveFXRates(ctx context.Context, rates []fx.Rate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rate := range rates {
+		date := truncateToDate(rate.Date)
+		s.rates[rateKey{currency: rate.Currency, date: date}] = fx.Rate{
+			Currency: rate.Currency,
+			Date:     date,
+			Rate:     roundTo(rate.Rate, 8),
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) GetFXRates(ctx context.Context, currencies []string) ([]fx.Rate, error) {
+	if len(currencies) == 0 {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(currencies))
+	for _, currency := range currencies {
+		wanted[currency] = true
+	}
+
+	var rates []fx.Rate
+	for key, rate := range s.rates {
+		if wanted[key.currency] {
+			rates = append(rates, rate)
+		}
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Currency != rates[j].Currency {
+			return rates[i].Currency < rates[j].Currency
+		}
+		return rates[i].Date.Before(rates[j].Date)
+	})
+
+	return rates, nil
+}
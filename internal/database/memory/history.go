@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+// changedBy stands in for the database role that the PostgreSQL trigger records.
+const changedBy = "memory"
+
+func (s *Store) GetFinancialHistory(ctx context.Context, company string, year int, quarter, periodType, metric string) ([]database.FinancialChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	changes := make([]database.FinancialChange, 0)
+	c := s.companyByTicker(company, false)
+	if c == nil {
+		return changes, nil
+	}
+
+	key := financialKey{companyID: c.ID, year: year, quarter: quarter, periodType: periodType}
+	for _, entry := range s.history {
+		if entry.key == key && (metric == "" || entry.change.Metric == metric) {
+			changes = append(changes, entry.change)
+		}
+	}
+
+	// Entries are appended in time order, so a stable sort keeps changed_at, id within a metric.
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Metric < changes[j].Metric })
+
+	return changes, nil
+}
+
+func (s *Store) GetRestatements(ctx context.Context, companies []string, metric string, periodType string) ([]database.Restatement, error) {
+	def, ok := metrics.Lookup(metric)
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %s", metric)
+	}
+
+	if len(companies) == 0 {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(companies))
+	for _, company := range companies {
+		wanted[company] = true
+	}
+
+	byCell := make(map[financialKey]*database.Restatement)
+	var restatements []*database.Restatement
+	for _, entry := range s.history {
+		c := s.companies[entry.key.companyID]
+		if c.deletedAt != nil || !wanted[c.Ticker] || entry.key.periodType != periodType ||
+			entry.change.Metric != def.Key || entry.change.OldValue == nil {
+			continue
+		}
+
+		restatement, ok := byCell[entry.key]
+		if !ok {
+			restatement = &database.Restatement{Company: c.Ticker, Year: entry.key.year, Quarter: entry.key.quarter}
+			byCell[entry.key] = restatement
+			restatements = append(restatements, restatement)
+		}
+		restatement.Count++
+		if entry.change.ChangedAt.After(restatement.LastChangedAt) {
+			restatement.LastChangedAt = entry.change.ChangedAt
+		}
+	}
+
+	result := make([]database.Restatement, 0, len(restatements))
+	for _, restatement := range restatements {
+		result = append(result, *restatement)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Company != b.Company {
+			return a.Company < b.Company
+		}
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		return a.Quarter < b.Quarter
+	})
+
+	return result, nil
+}
+
+// recordChange mirrors the financial_values history trigger of migrations 000017-000019; it must be
+// called with s.mu held.
+func (s *Store) recordChange(key financialKey, metric string, previous, current models.NullFloat64, source string, runID int64) {
+	if previous.Valid == current.Valid && (!previous.Valid || previous.Float64 == current.Float64) {
+		return
+	}
+
+	change := database.FinancialChange{
+		Metric:    metric,
+		Source:    source,
+		ChangedBy: changedBy,
+		ChangedAt: s.now(),
+	}
+	if previous.Valid {
+		change.OldValue = &previous.Float64
+	}
+	if current.Valid {
+		change.NewValue = &current.Float64
+	}
+	if source == database.ChangeSourceImport {
+		change.ImportRunID = &runID
+	}
+
+	s.history = append(s.history, historyEntry{key: key, change: change})
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+func (s *Store) StartImportRun(ctx context.Context, sourcePath string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run := database.ImportRun{
+		ID:         int64(len(s.runs) + 1),
+		SourcePath: sourcePath,
+		Status:     database.ImportRunRunning,
+		StartedAt:  s.now(),
+	}
+	s.runs = append(s.runs, run)
+
+	return run.ID, nil
+}
+
+func (s *Store) FinishImportRun(ctx context.Context, run database.ImportRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.run(run.ID)
+	if stored == nil {
+		return nil
+	}
+
+	finishedAt := s.now()
+	stored.Status = run.Status
+	stored.FilesTotal = run.FilesTotal
+	stored.FilesFailed = run.FilesFailed
+	stored.FilesSkipped = run.FilesSkipped
+	stored.RowsParsed = run.RowsParsed
+	stored.RowsWritten = run.RowsWritten
+	stored.Error = run.Error
+	stored.FinishedAt = &finishedAt
+
+	return nil
+}
+
+func (s *Store) SaveImportFile(ctx context.Context, file database.ImportFile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.run(file.RunID) == nil {
+		return fmt.Errorf("error saving import file %s: import run %d not found", file.Path, file.RunID)
+	}
+	s.files = append(s.files, file)
+
+	return nil
+}
+
+func (s *Store) GetImportRuns(ctx context.Context, limit int) ([]database.ImportRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runs := make([]database.ImportRun, len(s.runs))
+	copy(runs, s.runs)
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].StartedAt.Equal(runs[j].StartedAt) {
+			return runs[i].StartedAt.After(runs[j].StartedAt)
+		}
+		return runs[i].ID > runs[j].ID
+	})
+
+	if limit >= 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	return runs, nil
+}
+
+func (s *Store) GetImportedChecksums(ctx context.Context) (map[string]models.FileChecksum, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Files are appended in insertion order, so the last match per path wins like ORDER BY id DESC.
+	checksums := make(map[string]models.FileChecksum)
+	for _, file := range s.files {
+		if file.Status == database.ImportFileImported || file.Status == database.ImportFileSkipped {
+			checksums[file.Path] = models.FileChecksum{SHA256: file.SHA256, MappingSHA256: file.MappingSHA256}
+		}
+	}
+
+	return checksums, nil
+}
+
+// run must be called with s.mu held.
+func (s *Store) run(id int64) *database.ImportRun {
+	for i := range s.runs {
+		if s.runs[i].ID == id {
+			return &s.runs[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/database/dbtest"
+	"github.com/VxVxN/financialanalyzer/internal/database/memory"
+)
+
+func TestStore(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) database.Store {
+		return memory.New()
+	})
+}
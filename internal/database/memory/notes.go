@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+)
+
+func (s *Store) GetCompanyNote(ctx context.Context, company string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c := s.companyByTicker(company, false)
+	if c == nil {
+		return "", nil
+	}
+
+	return s.notes[c.ID], nil
+}
+
+func (s *Store) SaveCompanyNote(ctx context.Context, company, note string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.companyByTicker(company, false)
+	if c == nil {
+		return fmt.Errorf("company %s not found", company)
+	}
+	s.notes[c.ID] = note
+
+	return nil
+}
+
+func (s *Store) DeleteCompanyNote(ctx context.Context, company string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c := s.companyByTicker(company, false); c != nil {
+		delete(s.notes, c.ID)
+	}
+
+	return nil
+}
+
+func (s *Store) SaveCompanyColor(ctx context.Context, company, color string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.companyByTicker(company, false)
+	if c == nil {
+		return fmt.Errorf("company %s not found", company)
+	}
+	s.colors[c.ID] = color
+
+	return nil
+}
+
+func (s *Store) GetCompaniesColors(ctx context.Context, companies []string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	colors := make(map[string]string)
+	for _, company := range companies {
+		c := s.companyByTicker(company, false)
+		if c == nil {
+			continue
+		}
+		if color, ok := s.colors[c.ID]; ok {
+			colors[company] = color
+		}
+	}
+
+	return colors, nil
+}
+
+func (s *Store) DeleteCompanyColor(ctx context.Context, company string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c := s.companyByTicker(company, false); c != nil {
+		delete(s.colors, c.ID)
+	}
+
+	return nil
+}
+
+func (s *Store) GetCompaniesColorsByCategory(ctx context.Context, category string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	colors := make(map[string]string)
+	for _, c := range s.activeCompanies() {
+		if category != "" && c.Category != category {
+			continue
+		}
+		if color, ok := s.colors[c.ID]; ok {
+			colors[c.Ticker] = color
+		}
+	}
+
+	return colors, nil
+}
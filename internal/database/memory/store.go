@@ -0,0 +1,95 @@
+// Package memory is an in-process implementation of database.Store that mirrors the PostgreSQL
+// repository, including its rounding of stored values, for tests and local experiments.
+package memory
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/fx"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+type company struct {
+	models.Company
+	deletedAt *time.Time
+}
+
+type financialKey struct {
+	companyID  int64
+	year       int
+	quarter    string
+	periodType string
+}
+
+// financialRow is a stored period; runID is the run that last changed its report date or currency.
+type financialRow struct {
+	data  models.QuarterData
+	runID int64
+}
+
+type historyEntry struct {
+	key    financialKey
+	change database.FinancialChange
+}
+
+type rateKey struct {
+	currency string
+	date     time.Time
+}
+
+type Store struct {
+	mu  sync.RWMutex
+	now func() time.Time
+
+	nextCompanyID int64
+	companies     map[int64]*company
+	financials    map[financialKey]*financialRow
+	notes         map[int64]string
+	colors        map[int64]string
+	aliases       map[string]int64
+	history       []historyEntry
+
+	runs  []database.ImportRun
+	files []database.ImportFile
+	rates map[rateKey]fx.Rate
+}
+
+var _ database.Store = (*Store)(nil)
+
+func New() *Store {
+	return &Store{
+		now:        func() time.Time { return time.Now().UTC() },
+		companies:  make(map[int64]*company),
+		financials: make(map[financialKey]*financialRow),
+		notes:      make(map[int64]string),
+		colors:     make(map[int64]string),
+		aliases:    make(map[string]int64),
+		rates:      make(map[rateKey]fx.Rate),
+	}
+}
+
+// companyByTicker must be called with s.mu held.
+func (s *Store) companyByTicker(ticker string, includeDeleted bool) *company {
+	for _, c := range s.companies {
+		if c.Ticker == ticker && (includeDeleted || c.deletedAt == nil) {
+			return c
+		}
+	}
+	return nil
+}
+
+// roundTo mirrors PostgreSQL NUMERIC(p, scale) columns.
+func roundTo(value float64, scale int) float64 {
+	factor := math.Pow(10, float64(scale))
+	return math.Round(value*factor) / factor
+}
+
+func truncateToDate(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
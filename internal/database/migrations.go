@@ -4,25 +4,40 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
 
+	"github.com/VxVxN/financialanalyzer/migrations"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/lib/pq"
 )
 
-func RunMigrations(db *sql.DB) error {
+// NewMigrator returns a migrate instance for db that reads the embedded migrations. It holds a
+// connection of db until closed, and closing it closes db too.
+func NewMigrator(db *sql.DB) (*migrate.Migrate, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded migrations: %w", err)
+	}
+
 	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration instance: %w", err)
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres",
-		driver,
-	)
+	return m, nil
+}
+
+func RunMigrations(db *sql.DB) error {
+	m, err := NewMigrator(db)
 	if err != nil {
-		return fmt.Errorf("failed to create migration instance: %w", err)
+		return err
 	}
 
 	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
@@ -31,3 +46,57 @@ func RunMigrations(db *sql.DB) error {
 
 	return nil
 }
+
+// CheckSchema fails unless db is clean and at the latest embedded migration. The binaries no longer
+// migrate on start, so this turns a forgotten `migrate up` into a clear error instead of broken queries.
+func CheckSchema(db *sql.DB) error {
+	// Read the version table directly: a migrate instance pins a pooled connection until it is closed,
+	// and closing it closes db as well.
+	var version uint
+	var dirty bool
+	err := db.QueryRow(`SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.As(err, &pqErr) && pqErr.Code.Name() == "undefined_table":
+		version = 0
+	case err != nil:
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("database schema is dirty at version %d; fix it and run migrate force", version)
+	}
+
+	latest, err := LatestMigration()
+	if err != nil {
+		return err
+	}
+	if version != latest {
+		return fmt.Errorf("database schema is at version %d, expected %d; run migrate up", version, latest)
+	}
+
+	return nil
+}
+
+// LatestMigration returns the version of the newest embedded migration.
+func LatestMigration() (uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, fmt.Errorf("failed to open embedded migrations: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read embedded migrations: %w", err)
+		}
+		version = next
+	}
+}
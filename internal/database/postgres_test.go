@@ -0,0 +1,41 @@
+package database_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/database/dbtest"
+)
+
+// TestRepository runs the conformance suite against a real database. It is skipped unless
+// TEST_DATABASE_URL points to a disposable PostgreSQL database, whose tables are truncated.
+func TestRepository(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	dbtest.Run(t, func(t *testing.T) database.Store {
+		_, err := db.Exec(`
+            TRUNCATE companies, company_financials, company_notes, company_colors, company_aliases,
+                import_files, import_runs, fx_rates
+            RESTART IDENTITY CASCADE
+        `)
+		if err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+		return database.NewRepository(db)
+	})
+}
@@ -1,13 +1,17 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
 	"github.com/VxVxN/financialanalyzer/internal/models"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -18,63 +22,227 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) SaveQuarterData(data models.QuarterData) error {
-	query := `
-    INSERT INTO company_financials (year, quarter, company, category, capitalization, revenue, net_profit, ebitda, debt, pe, ps, roe, roa, capex, opex, dividends)
-    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
-    ON CONFLICT (year, quarter, company) 
-    DO UPDATE SET
-        capitalization = COALESCE(EXCLUDED.capitalization, company_financials.capitalization),
-        revenue = COALESCE(EXCLUDED.revenue, company_financials.revenue),
-        net_profit = COALESCE(EXCLUDED.net_profit, company_financials.net_profit),
-        ebitda = COALESCE(EXCLUDED.ebitda, company_financials.ebitda),
-        debt = COALESCE(EXCLUDED.debt, company_financials.debt),
-        pe = COALESCE(EXCLUDED.pe, company_financials.pe),
-        ps = COALESCE(EXCLUDED.ps, company_financials.ps),
-        roe = COALESCE(EXCLUDED.roe, company_financials.roe),
-        roa = COALESCE(EXCLUDED.roa, company_financials.roa),
-        capex = COALESCE(EXCLUDED.capex, company_financials.capex),
-        opex = COALESCE(EXCLUDED.opex, company_financials.opex),
-        dividends = COALESCE(EXCLUDED.dividends, company_financials.dividends)
-        `
-
-	_, err := r.db.Exec(query,
-		data.Year,
-		data.Quarter,
-		data.Company,
-		data.Category,
-		nullIfZero(data.Capitalization),
-		nullIfZero(data.Revenue),
-		nullIfZero(data.NetProfit),
-		nullIfZero(data.EBITDA),
-		nullIfZero(data.Debt),
-		nullIfZero(data.PE),
-		nullIfZero(data.PS),
-		nullIfZero(data.ROE),
-		nullIfZero(data.ROA),
-		nullIfZero(data.CAPEX),
-		nullIfZero(data.OPEX),
-		nullIfZero(data.Dividends),
-	)
-
-	return err
+func (r *Repository) SaveQuarterDataBatch(ctx context.Context, runID int64, mode models.ImportMode, companies []models.Company, data []models.QuarterData) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setChangeSource(ctx, tx, ChangeSourceImport); err != nil {
+		return err
+	}
+	if err := setImportRun(ctx, tx, runID); err != nil {
+		return err
+	}
+
+	companyIDs, err := ensureCompanies(ctx, tx, companies, data)
+	if err != nil {
+		return err
+	}
+
+	columns := []string{"year", "quarter", "period_type", "company_id", "import_run_id", "report_date", "currency"}
+	columnList := strings.Join(columns, ", ")
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+        CREATE TEMP TABLE company_financials_staging ON COMMIT DROP AS
+        SELECT %s FROM company_financials WITH NO DATA
+    `, columnList))
+	if err != nil {
+		return fmt.Errorf("failed to create staging table: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        CREATE TEMP TABLE financial_values_staging (
+            year INTEGER NOT NULL,
+            quarter VARCHAR(2) NOT NULL,
+            period_type VARCHAR(10) NOT NULL,
+            company_id INTEGER NOT NULL,
+            metric_key VARCHAR(50) NOT NULL,
+            value NUMERIC(20,2),
+            cleared BOOLEAN NOT NULL
+        ) ON COMMIT DROP
+    `)
+	if err != nil {
+		return fmt.Errorf("failed to create value staging table: %w", err)
+	}
+
+	periods := make([][]interface{}, 0, len(data))
+	var values [][]interface{}
+	for _, item := range data {
+		key := []interface{}{item.Year, item.Quarter, periodType(item), companyIDs[item.Company]}
+		periods = append(periods, append(key, runID, nullableDate(item.ReportDate), nullableString(item.Currency)))
+
+		for _, metricKey := range sortedKeys(item.Values) {
+			value := item.Values[metricKey]
+			if value.IsMissing() {
+				continue
+			}
+			values = append(values, append(key[:4:4], metricKey, nullableValue(value), value.Cleared))
+		}
+	}
+
+	if err := copyRows(ctx, tx, "company_financials_staging", columns, periods); err != nil {
+		return err
+	}
+	valueColumns := []string{"year", "quarter", "period_type", "company_id", "metric_key", "value", "cleared"}
+	if err := copyRows(ctx, tx, "financial_values_staging", valueColumns, values); err != nil {
+		return err
+	}
+
+	reportDate, currency := "COALESCE(s.report_date, cf.report_date)", "COALESCE(s.currency, cf.currency)"
+	if mode == models.ImportModeReplace {
+		reportDate, currency = "s.report_date", "s.currency"
+	}
+
+	// A period keeps the run that last changed its attributes; values carry their own run.
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+        UPDATE company_financials cf
+        SET import_run_id = s.import_run_id,
+            report_date = %[1]s,
+            currency = %[2]s
+        FROM company_financials_staging s
+        WHERE cf.year = s.year AND cf.quarter = s.quarter AND cf.period_type = s.period_type AND cf.company_id = s.company_id
+          AND (%[1]s, %[2]s) IS DISTINCT FROM (cf.report_date, cf.currency)
+    `, reportDate, currency))
+	if err != nil {
+		return fmt.Errorf("failed to update existing rows: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+        INSERT INTO company_financials (%[1]s)
+        SELECT %[1]s FROM company_financials_staging s
+        WHERE NOT EXISTS (
+            SELECT 1 FROM company_financials cf
+            WHERE cf.year = s.year AND cf.quarter = s.quarter AND cf.period_type = s.period_type AND cf.company_id = s.company_id
+        )
+    `, columnList))
+	if err != nil {
+		return fmt.Errorf("failed to insert new rows: %w", err)
+	}
+
+	// Merge mode only removes the values the file clears, replace mode every value the file does not set.
+	removed := "v.metric_key IN (SELECT metric_key FROM financial_values_staging sv WHERE sv.cleared AND " + stagedPeriod + ")"
+	if mode == models.ImportModeReplace {
+		removed = "v.metric_key NOT IN (SELECT metric_key FROM financial_values_staging sv WHERE NOT sv.cleared AND " + stagedPeriod + ")"
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+        DELETE FROM financial_values v
+        USING company_financials cf, company_financials_staging s
+        WHERE v.financial_id = cf.id
+          AND cf.year = s.year AND cf.quarter = s.quarter AND cf.period_type = s.period_type AND cf.company_id = s.company_id
+          AND %s
+    `, removed))
+	if err != nil {
+		return fmt.Errorf("failed to remove cleared values: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO financial_values (financial_id, metric_key, value, import_run_id)
+        SELECT cf.id, sv.metric_key, sv.value, $1
+        FROM financial_values_staging sv
+        JOIN company_financials cf
+          ON cf.year = sv.year AND cf.quarter = sv.quarter AND cf.period_type = sv.period_type AND cf.company_id = sv.company_id
+        WHERE NOT sv.cleared
+        ON CONFLICT (financial_id, metric_key)
+        DO UPDATE SET value = EXCLUDED.value, import_run_id = EXCLUDED.import_run_id
+        WHERE financial_values.value IS DISTINCT FROM EXCLUDED.value
+    `, runID)
+	if err != nil {
+		return fmt.Errorf("failed to save values: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+const periodOrder = `CASE cf.quarter
+                WHEN 'Q1' THEN 1
+                WHEN 'Q2' THEN 2
+                WHEN 'H1' THEN 3
+                WHEN 'Q3' THEN 4
+                WHEN 'Q4' THEN 5
+                WHEN 'H2' THEN 6
+                WHEN 'FY' THEN 7
+            END`
+
+// stagedPeriod matches staged values (sv) against the stored period cf.
+const stagedPeriod = "sv.year = cf.year AND sv.quarter = cf.quarter AND sv.period_type = cf.period_type AND sv.company_id = cf.company_id"
+
+func copyRows(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]interface{}) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return fmt.Errorf("failed to prepare copy into %s: %w", table, err)
+	}
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			stmt.Close()
+			return fmt.Errorf("failed to copy row into %s: %w", table, err)
+		}
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return fmt.Errorf("failed to flush copy into %s: %w", table, err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("failed to close copy into %s: %w", table, err)
+	}
+
+	return nil
+}
+
+func sortedKeys(values map[string]models.NullFloat64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func periodType(item models.QuarterData) string {
+	if item.PeriodType == "" {
+		return models.PeriodQuarter
+	}
+	return item.PeriodType
+}
+
+func nullableValue(value models.NullFloat64) interface{} {
+	if !value.Valid {
+		return nil
+	}
+	return value.Float64
 }
 
-func nullIfZero(val float64) interface{} {
-	if val == 0 {
+func nullableDate(date time.Time) interface{} {
+	if date.IsZero() {
 		return nil
 	}
-	return val
+	return date
 }
 
-type CompanyMetric struct {
-	Year    int
-	Quarter string
-	Company string
-	Value   float64
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
 }
 
-func (r *Repository) GetCompaniesMetric(companies []string, metric string) ([]CompanyMetric, error) {
+func (r *Repository) GetQuarterData(ctx context.Context, companies []string) ([]models.QuarterData, error) {
+	if len(companies) == 0 {
+		return nil, nil
+	}
+
 	placeholders := make([]string, len(companies))
 	args := make([]interface{}, len(companies))
 	for i, company := range companies {
@@ -83,40 +251,45 @@ func (r *Repository) GetCompaniesMetric(companies []string, metric string) ([]Co
 	}
 
 	query := fmt.Sprintf(`
-        SELECT year, quarter, company, %s as value
-        FROM company_financials
-        WHERE company IN (%s)
-        ORDER BY year, 
-            CASE quarter
-                WHEN 'Q1' THEN 1
-                WHEN 'Q2' THEN 2
-                WHEN 'Q3' THEN 3
-                WHEN 'Q4' THEN 4
-            END
-    `, metric, strings.Join(placeholders, ","))
+        SELECT cf.id, cf.year, cf.quarter, cf.period_type, c.ticker, c.category, cf.report_date, cf.currency, v.metric_key, v.value
+        FROM company_financials cf
+        JOIN companies c ON c.id = cf.company_id
+        LEFT JOIN financial_values v ON v.financial_id = cf.id
+        WHERE c.ticker IN (%s) AND c.deleted_at IS NULL
+        ORDER BY c.ticker, cf.year, %s, cf.period_type, cf.id
+    `, strings.Join(placeholders, ","), periodOrder)
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query metric %s: %w", metric, err)
+		return nil, fmt.Errorf("failed to query quarter data: %w", err)
 	}
 	defer rows.Close()
 
-	var result []CompanyMetric
+	// Every value is a row of its own, the rows of a period are adjacent.
+	var result []models.QuarterData
+	lastID := int64(-1)
 	for rows.Next() {
-		var item CompanyMetric
+		var id int64
+		var item models.QuarterData
+		var reportDate sql.NullTime
+		var currency, metricKey sql.NullString
 		var value sql.NullFloat64
 
-		if err := rows.Scan(&item.Year, &item.Quarter, &item.Company, &value); err != nil {
+		err := rows.Scan(&id, &item.Year, &item.Quarter, &item.PeriodType, &item.Company, &item.Category, &reportDate, &currency, &metricKey, &value)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
-		if value.Valid {
-			item.Value = value.Float64
-		} else {
-			item.Value = 0
+		if id != lastID {
+			item.ReportDate = reportDate.Time
+			item.Currency = currency.String
+			result = append(result, item)
+			lastID = id
 		}
 
-		result = append(result, item)
+		if metricKey.Valid {
+			result[len(result)-1].SetValue(metricKey.String, models.NullFloat64{Float64: value.Float64, Valid: value.Valid})
+		}
 	}
 
 	if err = rows.Err(); err != nil {
@@ -126,99 +299,102 @@ func (r *Repository) GetCompaniesMetric(companies []string, metric string) ([]Co
 	return result, nil
 }
 
-func (r *Repository) GetAllCompanies() ([]string, error) {
-	rows, err := r.db.Query(`
-        SELECT DISTINCT company 
-        FROM company_financials 
-        WHERE company IS NOT NULL AND company != ''
-        ORDER BY company
-    `)
-	if err != nil {
-		return nil, err
+func (r *Repository) GetCompaniesMetric(ctx context.Context, companies []string, metric string, periodType string) ([]models.CompanyMetric, error) {
+	def, ok := metrics.Lookup(metric)
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %s", metric)
 	}
-	defer rows.Close()
 
-	var companies []string
-	for rows.Next() {
-		var company string
-		if err := rows.Scan(&company); err != nil {
-			return nil, err
-		}
-		companies = append(companies, company)
+	if len(companies) == 0 {
+		return nil, nil
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+	placeholders := make([]string, len(companies))
+	args := make([]interface{}, len(companies))
+	for i, company := range companies {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = company
 	}
+	args = append(args, def.Key, periodType)
 
-	return companies, nil
-}
-
-func (r *Repository) GetAllCategories() ([]string, error) {
-	query := `SELECT DISTINCT category FROM company_financials WHERE category IS NOT NULL AND category != '' ORDER BY category`
+	query := fmt.Sprintf(`
+        SELECT cf.year, cf.quarter, c.ticker, v.value, cf.report_date, cf.currency
+        FROM company_financials cf
+        JOIN companies c ON c.id = cf.company_id
+        JOIN financial_values v ON v.financial_id = cf.id AND v.metric_key = $%d
+        WHERE c.ticker IN (%s) AND c.deleted_at IS NULL AND cf.period_type = $%d
+        ORDER BY cf.year, %s, c.ticker
+    `, len(args)-1, strings.Join(placeholders, ","), len(args), periodOrder)
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("error getting categories: %w", err)
+		return nil, fmt.Errorf("failed to query metric %s: %w", metric, err)
 	}
 	defer rows.Close()
 
-	var categories []string
-	for rows.Next() {
-		var category string
-		if err := rows.Scan(&category); err != nil {
-			return nil, fmt.Errorf("error scanning category: %w", err)
-		}
-		categories = append(categories, category)
+	return scanCompanyMetrics(rows)
+}
+
+func (r *Repository) GetLatestLTM(ctx context.Context, companies []string, metric string) ([]models.CompanyMetric, error) {
+	def, ok := metrics.Lookup(metric)
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %s", metric)
 	}
 
-	return categories, nil
-}
+	if len(companies) == 0 {
+		return nil, nil
+	}
 
-type CompanyWithCategory struct {
-	Company  string `json:"company"`
-	Category string `json:"category"`
-}
+	placeholders := make([]string, len(companies))
+	args := make([]interface{}, len(companies))
+	for i, company := range companies {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = company
+	}
+	args = append(args, def.Key)
 
-func (r *Repository) GetAllCompaniesWithCategories() ([]CompanyWithCategory, error) {
-	query := `SELECT DISTINCT company, category FROM company_financials ORDER BY company`
+	query := fmt.Sprintf(`
+        SELECT DISTINCT ON (c.ticker) cf.year, cf.quarter, c.ticker, v.value, cf.report_date, cf.currency
+        FROM company_financials cf
+        JOIN companies c ON c.id = cf.company_id
+        JOIN financial_values v ON v.financial_id = cf.id AND v.metric_key = $%d
+        WHERE c.ticker IN (%s) AND c.deleted_at IS NULL AND cf.period_type = 'ltm'
+        ORDER BY c.ticker, cf.year DESC, %s DESC
+    `, len(args), strings.Join(placeholders, ","), periodOrder)
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("error getting companies with categories: %w", err)
+		return nil, fmt.Errorf("failed to query LTM %s: %w", metric, err)
 	}
 	defer rows.Close()
 
-	var companies []CompanyWithCategory
-	for rows.Next() {
-		var c CompanyWithCategory
-		if err := rows.Scan(&c.Company, &c.Category); err != nil {
-			return nil, fmt.Errorf("error scanning company with category: %w", err)
-		}
-		companies = append(companies, c)
-	}
-
-	return companies, nil
+	return scanCompanyMetrics(rows)
 }
 
-func (r *Repository) DeleteCompany(company string) error {
-	query := `DELETE FROM company_financials WHERE company = $1`
+func scanCompanyMetrics(rows *sql.Rows) ([]models.CompanyMetric, error) {
+	var result []models.CompanyMetric
+	for rows.Next() {
+		var item models.CompanyMetric
+		var reportDate sql.NullTime
+		var currency sql.NullString
 
-	result, err := r.db.Exec(query, company)
-	if err != nil {
-		return fmt.Errorf("error deleting company %s: %w", company, err)
-	}
+		if err := rows.Scan(&item.Year, &item.Quarter, &item.Company, &item.Value, &reportDate, &currency); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error getting rows affected: %w", err)
+		if reportDate.Valid {
+			item.ReportDate = &reportDate.Time
+		}
+		item.Currency = currency.String
+
+		result = append(result, item)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("company %s not found", company)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
 
 type CompanyNote struct {
@@ -228,11 +404,16 @@ type CompanyNote struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-func (r *Repository) GetCompanyNote(company string) (string, error) {
-	query := `SELECT note FROM company_notes WHERE company = $1`
+func (r *Repository) GetCompanyNote(ctx context.Context, company string) (string, error) {
+	query := `
+        SELECT cn.note
+        FROM company_notes cn
+        JOIN companies c ON c.id = cn.company_id
+        WHERE c.ticker = $1 AND c.deleted_at IS NULL
+    `
 
 	var note sql.NullString
-	err := r.db.QueryRow(query, company).Scan(&note)
+	err := r.db.QueryRowContext(ctx, query, company).Scan(&note)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", nil
@@ -246,28 +427,28 @@ func (r *Repository) GetCompanyNote(company string) (string, error) {
 	return "", nil
 }
 
-func (r *Repository) SaveCompanyNote(company, note string) error {
+func (r *Repository) SaveCompanyNote(ctx context.Context, company, note string) error {
 	query := `
-        INSERT INTO company_notes (company, note, updated_at)
-        VALUES ($1, $2, CURRENT_TIMESTAMP)
-        ON CONFLICT (company) 
+        INSERT INTO company_notes (company_id, note, updated_at)
+        SELECT id, $2, CURRENT_TIMESTAMP FROM companies WHERE ticker = $1 AND deleted_at IS NULL
+        ON CONFLICT (company_id) 
         DO UPDATE SET
             note = EXCLUDED.note,
             updated_at = CURRENT_TIMESTAMP
     `
 
-	_, err := r.db.Exec(query, company, note)
+	result, err := r.db.ExecContext(ctx, query, company, note)
 	if err != nil {
 		return fmt.Errorf("error saving company note: %w", err)
 	}
 
-	return nil
+	return requireCompany(result, company)
 }
 
-func (r *Repository) DeleteCompanyNote(company string) error {
-	query := `DELETE FROM company_notes WHERE company = $1`
+func (r *Repository) DeleteCompanyNote(ctx context.Context, company string) error {
+	query := `DELETE FROM company_notes WHERE company_id = (SELECT id FROM companies WHERE ticker = $1 AND deleted_at IS NULL)`
 
-	_, err := r.db.Exec(query, company)
+	_, err := r.db.ExecContext(ctx, query, company)
 	if err != nil {
 		return fmt.Errorf("error deleting company note: %w", err)
 	}
@@ -280,25 +461,25 @@ type CompanyColor struct {
 	Color   string `json:"color"`
 }
 
-func (r *Repository) SaveCompanyColor(company, color string) error {
+func (r *Repository) SaveCompanyColor(ctx context.Context, company, color string) error {
 	query := `
-        INSERT INTO company_colors (company, color, updated_at)
-        VALUES ($1, $2, CURRENT_TIMESTAMP)
-        ON CONFLICT (company) 
+        INSERT INTO company_colors (company_id, color, updated_at)
+        SELECT id, $2, CURRENT_TIMESTAMP FROM companies WHERE ticker = $1 AND deleted_at IS NULL
+        ON CONFLICT (company_id) 
         DO UPDATE SET
             color = EXCLUDED.color,
             updated_at = CURRENT_TIMESTAMP
     `
 
-	_, err := r.db.Exec(query, company, color)
+	result, err := r.db.ExecContext(ctx, query, company, color)
 	if err != nil {
 		return fmt.Errorf("error saving company color: %w", err)
 	}
 
-	return nil
+	return requireCompany(result, company)
 }
 
-func (r *Repository) GetCompaniesColors(companies []string) (map[string]string, error) {
+func (r *Repository) GetCompaniesColors(ctx context.Context, companies []string) (map[string]string, error) {
 	if len(companies) == 0 {
 		return make(map[string]string), nil
 	}
@@ -311,12 +492,13 @@ func (r *Repository) GetCompaniesColors(companies []string) (map[string]string,
 	}
 
 	query := fmt.Sprintf(`
-        SELECT company, color 
-        FROM company_colors 
-        WHERE company IN (%s)
+        SELECT c.ticker, cc.color 
+        FROM company_colors cc
+        JOIN companies c ON c.id = cc.company_id
+        WHERE c.ticker IN (%s) AND c.deleted_at IS NULL
     `, strings.Join(placeholders, ","))
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error getting companies colors: %w", err)
 	}
@@ -334,10 +516,10 @@ func (r *Repository) GetCompaniesColors(companies []string) (map[string]string,
 	return colors, nil
 }
 
-func (r *Repository) DeleteCompanyColor(company string) error {
-	query := `DELETE FROM company_colors WHERE company = $1`
+func (r *Repository) DeleteCompanyColor(ctx context.Context, company string) error {
+	query := `DELETE FROM company_colors WHERE company_id = (SELECT id FROM companies WHERE ticker = $1 AND deleted_at IS NULL)`
 
-	_, err := r.db.Exec(query, company)
+	_, err := r.db.ExecContext(ctx, query, company)
 	if err != nil {
 		return fmt.Errorf("error deleting company color: %w", err)
 	}
@@ -345,19 +527,20 @@ func (r *Repository) DeleteCompanyColor(company string) error {
 	return nil
 }
 
-func (r *Repository) GetCompaniesColorsByCategory(category string) (map[string]string, error) {
+func (r *Repository) GetCompaniesColorsByCategory(ctx context.Context, category string) (map[string]string, error) {
 	query := `
-        SELECT DISTINCT cf.company, cc.color 
-        FROM company_financials cf
-        LEFT JOIN company_colors cc ON cf.company = cc.company
+        SELECT c.ticker, cc.color 
+        FROM companies c
+        LEFT JOIN company_colors cc ON cc.company_id = c.id
+        WHERE c.deleted_at IS NULL
     `
 	var args []interface{}
 	if category != "" {
-		query = query + "WHERE cf.category = $1"
+		query = query + "AND c.category = $1"
 		args = append(args, category)
 	}
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error getting companies colors by category: %w", err)
 	}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/fx"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+type FinancialsRepository interface {
+	SaveQuarterDataBatch(ctx context.Context, runID int64, mode models.ImportMode, companies []models.Company, data []models.QuarterData) error
+	GetQuarterData(ctx context.Context, companies []string) ([]models.QuarterData, error)
+	GetCompaniesMetric(ctx context.Context, companies []string, metric string, periodType string) ([]models.CompanyMetric, error)
+	GetLatestLTM(ctx context.Context, companies []string, metric string) ([]models.CompanyMetric, error)
+}
+
+type HistoryRepository interface {
+	GetFinancialHistory(ctx context.Context, company string, year int, quarter, periodType, metric string) ([]FinancialChange, error)
+	GetRestatements(ctx context.Context, companies []string, metric string, periodType string) ([]Restatement, error)
+}
+
+type CompaniesRepository interface {
+	GetAllCompanies(ctx context.Context) ([]string, error)
+	GetCompanies(ctx context.Context) ([]models.Company, error)
+	GetAllCategories(ctx context.Context) ([]string, error)
+	GetAllCompaniesWithCategories(ctx context.Context) ([]CompanyWithCategory, error)
+	DeleteCompany(ctx context.Context, company string) (time.Time, error)
+	RestoreCompany(ctx context.Context, company string, retention time.Duration) error
+	PurgeDeletedCompanies(ctx context.Context, retention time.Duration) (int64, error)
+	RenameCompany(ctx context.Context, company, newTicker string) error
+	MergeCompanies(ctx context.Context, source, target string) error
+}
+
+type AliasesRepository interface {
+	GetCompanyAliases(ctx context.Context, company string) ([]CompanyAlias, error)
+	SaveCompanyAlias(ctx context.Context, alias, company string) error
+	DeleteCompanyAlias(ctx context.Context, alias string) error
+	ResolveAliases(ctx context.Context, tickers []string) (map[string]string, error)
+}
+
+type NotesRepository interface {
+	GetCompanyNote(ctx context.Context, company string) (string, error)
+	SaveCompanyNote(ctx context.Context, company, note string) error
+	DeleteCompanyNote(ctx context.Context, company string) error
+}
+
+type ColorsRepository interface {
+	SaveCompanyColor(ctx context.Context, company, color string) error
+	GetCompaniesColors(ctx context.Context, companies []string) (map[string]string, error)
+	DeleteCompanyColor(ctx context.Context, company string) error
+	GetCompaniesColorsByCategory(ctx context.Context, category string) (map[string]string, error)
+}
+
+type ImportRunsRepository interface {
+	StartImportRun(ctx context.Context, sourcePath string) (int64, error)
+	FinishImportRun(ctx context.Context, run ImportRun) error
+	SaveImportFile(ctx context.Context, file ImportFile) error
+	GetImportRuns(ctx context.Context, limit int) ([]ImportRun, error)
+	GetImportedChecksums(ctx context.Context) (map[string]models.FileChecksum, error)
+}
+
+type FXRatesRepository interface {
+	SaveFXRates(ctx context.Context, rates []fx.Rate) error
+	GetFXRates(ctx context.Context, currencies []string) ([]fx.Rate, error)
+}
+
+// Store is everything the application needs from storage. Repository implements it on PostgreSQL,
+// the memory package in process.
+type Store interface {
+	FinancialsRepository
+	HistoryRepository
+	CompaniesRepository
+	AliasesRepository
+	NotesRepository
+	ColorsRepository
+	ImportRunsRepository
+	FXRatesRepository
+}
+
+var _ Store = (*Repository)(nil)
@@ -0,0 +1,149 @@
+package fx
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const BaseCurrency = "RUB"
+
+var dateLayouts = []string{"2006-01-02", "02.01.2006"}
+
+// Rate is the price of one unit of Currency in BaseCurrency on Date.
+type Rate struct {
+	Currency string
+	Date     time.Time
+	Rate     float64
+}
+
+type Table struct {
+	rates map[string][]Rate
+}
+
+func NewTable(rates []Rate) *Table {
+	table := &Table{rates: make(map[string][]Rate)}
+	for _, rate := range rates {
+		table.rates[rate.Currency] = append(table.rates[rate.Currency], rate)
+	}
+	for _, currencyRates := range table.rates {
+		sort.Slice(currencyRates, func(i, j int) bool {
+			return currencyRates[i].Date.Before(currencyRates[j].Date)
+		})
+	}
+	return table
+}
+
+// Convert converts value from one currency to another using the latest rates
+// published on or before date.
+func (t *Table) Convert(value float64, from, to string, date time.Time) (float64, bool) {
+	if from == to {
+		return value, true
+	}
+
+	fromRate, ok := t.RateOn(from, date)
+	if !ok {
+		return 0, false
+	}
+	toRate, ok := t.RateOn(to, date)
+	if !ok {
+		return 0, false
+	}
+
+	return value * fromRate / toRate, true
+}
+
+// RateOn returns the price of currency in BaseCurrency from the latest rate published on or before date.
+func (t *Table) RateOn(currency string, date time.Time) (float64, bool) {
+	if currency == BaseCurrency {
+		return 1, true
+	}
+
+	rates := t.rates[currency]
+	idx := sort.Search(len(rates), func(i int) bool {
+		return rates[i].Date.After(date)
+	})
+	if idx == 0 {
+		return 0, false
+	}
+
+	return rates[idx-1].Rate, true
+}
+
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadCSV reads rates from a "date;currency;rate" file. A header row is allowed.
+func LoadCSV(path string) ([]Rate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fx rates file: %w", err)
+	}
+	defer file.Close()
+
+	return readCSV(file)
+}
+
+func readCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = ';'
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fx rates: %w", err)
+	}
+
+	var rates []Rate
+	for rowIdx, record := range records {
+		if len(record) < 3 {
+			return nil, fmt.Errorf("row %d: expected date, currency and rate", rowIdx+1)
+		}
+
+		date, err := parseDate(record[0])
+		if err != nil {
+			if rowIdx == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("row %d: %w", rowIdx+1, err)
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(record[1]))
+		if !ValidCurrency(currency) {
+			return nil, fmt.Errorf("row %d: invalid currency %q", rowIdx+1, record[1])
+		}
+
+		value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(record[2]), ",", "."), 64)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("row %d: invalid rate %q", rowIdx+1, record[2])
+		}
+
+		rates = append(rates, Rate{Currency: currency, Date: date, Rate: value})
+	}
+
+	return rates, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
@@ -0,0 +1,98 @@
+package fx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestTableConvert(t *testing.T) {
+	table := NewTable([]Rate{
+		{Currency: "USD", Date: date(2023, time.March, 1), Rate: 80},
+		{Currency: "USD", Date: date(2023, time.January, 1), Rate: 70},
+		{Currency: "EUR", Date: date(2023, time.January, 1), Rate: 100},
+	})
+
+	tests := []struct {
+		name     string
+		value    float64
+		from, to string
+		date     time.Time
+		want     float64
+		ok       bool
+	}{
+		{"rate on the date", 1, "USD", BaseCurrency, date(2023, time.March, 1), 80, true},
+		{"nearest earlier rate", 2, "USD", BaseCurrency, date(2023, time.February, 28), 140, true},
+		{"latest rate after the last one", 1, "USD", BaseCurrency, date(2024, time.January, 1), 80, true},
+		{"no rate before the first one", 1, "USD", BaseCurrency, date(2022, time.December, 31), 0, false},
+		{"into a foreign currency", 160, BaseCurrency, "USD", date(2023, time.March, 31), 2, true},
+		{"cross rate through RUB", 10, "USD", "EUR", date(2023, time.March, 31), 8, true},
+		{"same currency without rates", 5, "CNY", "CNY", date(2000, time.January, 1), 5, true},
+		{"unknown currency", 1, "CNY", BaseCurrency, date(2023, time.March, 31), 0, false},
+		{"unknown target currency", 1, "USD", "CNY", date(2023, time.March, 31), 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := table.Convert(tt.value, tt.from, tt.to, tt.date)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: Convert(%v, %s, %s, %s) = %v, %v; want %v, %v",
+				tt.name, tt.value, tt.from, tt.to, tt.date.Format("2006-01-02"), got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	content := "date;currency;rate\n2023-01-01;USD;70.5\n01.02.2023; eur ;80,25\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rates, err := LoadCSV(path)
+	if err != nil {
+		t.Fatalf("LoadCSV: %v", err)
+	}
+
+	want := []Rate{
+		{Currency: "USD", Date: date(2023, time.January, 1), Rate: 70.5},
+		{Currency: "EUR", Date: date(2023, time.February, 1), Rate: 80.25},
+	}
+	if !reflect.DeepEqual(rates, want) {
+		t.Errorf("LoadCSV = %+v, want %+v", rates, want)
+	}
+}
+
+func TestLoadCSVErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"2023-01-01;USD\n", "row 1: expected date, currency and rate"},
+		{"2023-01-01;USD;70\n2023-13-01;USD;71\n", `row 2: invalid date "2023-13-01"`},
+		{"2023-01-01;US1;70\n", `row 1: invalid currency "US1"`},
+		{"2023-01-01;USD;abc\n", `row 1: invalid rate "abc"`},
+		{"2023-01-01;USD;0\n", `row 1: invalid rate "0"`},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "rates.csv")
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadCSV(path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadCSV(%q) error = %v, want it to contain %q", tt.content, err, tt.want)
+		}
+	}
+
+	if _, err := LoadCSV(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Error("LoadCSV of a missing file must fail")
+	}
+}
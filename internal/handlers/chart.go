@@ -2,42 +2,40 @@ package handlers
 
 import (
 	"fmt"
+	"html"
 	"net/http"
-	"sort"
-	"strconv"
+	"net/url"
 	"strings"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/components"
 	"github.com/go-echarts/go-echarts/v2/opts"
 	"github.com/go-echarts/go-echarts/v2/types"
 
 	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
 )
 
 func (controller *Controller) ChartHandler(w http.ResponseWriter, r *http.Request) {
-	metric := chi.URLParam(r, "metric")
+	query, err := controller.parseMetricQuery(r)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	if query.transform, err = parseTransform(r); err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	companies := query.companies
+
 	theme := r.URL.Query().Get("theme")
-	companiesParam := r.URL.Query().Get("companies")
 	colorsParam := r.URL.Query().Get("colors")
 
 	if theme == "" {
 		theme = "light"
 	}
 
-	var companies []string
-	if companiesParam != "" {
-		companies = strings.Split(companiesParam, ",")
-	} else {
-		var err error
-		companies, err = controller.repo.GetAllCompanies()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
-
 	var companyColors map[string]string
 	if colorsParam != "" {
 		colors := strings.Split(colorsParam, ",")
@@ -49,13 +47,34 @@ func (controller *Controller) ChartHandler(w http.ResponseWriter, r *http.Reques
 		}
 	} else {
 		var err error
-		companyColors, err = controller.repo.GetCompaniesColors(companies)
+		companyColors, err = controller.repo.GetCompaniesColors(r.Context(), companies)
 		if err != nil {
 			companyColors = make(map[string]string)
 		}
 	}
 
-	data, err := controller.repo.GetCompaniesMetric(companies, metric)
+	data, warnings, err := controller.loadMetricData(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ltm, ltmWarnings, err := controller.loadLTM(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	warnings = append(warnings, ltmWarnings...)
+
+	// Transforms work on the converted values; an LTM figure has no place among them.
+	if query.transform != transformNone {
+		data = query.transform.apply(data)
+		ltm = nil
+	}
+	query.metric = query.transform.definition(query.metric)
+	metric := query.metric
+
+	restatements, err := controller.repo.GetRestatements(r.Context(), companies, metric.Key, query.periodType)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -93,31 +112,38 @@ func (controller *Controller) ChartHandler(w http.ResponseWriter, r *http.Reques
     <div class="chart-container">`, bgColor, textColor, bgColor)
 
 	page := components.NewPage()
-	page.PageTitle = fmt.Sprintf("%s - Financial Analyzer", formatMetricName(metric))
+	page.PageTitle = fmt.Sprintf("%s - Financial Analyzer", metric.DisplayName)
 
 	if len(data) > 0 {
-		lineChart := createNormalizedLineChart(data, metric, companies, companyColors)
+		lineChart := createNormalizedLineChart(data, query, companyColors)
 		page.AddCharts(lineChart)
 	}
 
 	page.Render(w)
 
 	fmt.Fprintf(w, `</div>`)
-	renderDataTable(w, data, companies, metric, theme)
+	renderWarnings(w, warnings)
+	renderDataTable(w, data, ltm, restatements, companies, metric, query.periodType, theme)
 
 	fmt.Fprintf(w, `</body></html>`)
 }
 
-func renderDataTable(w http.ResponseWriter, data []database.CompanyMetric, companies []string, metric, theme string) {
-	companyData := make(map[string]map[string]float64)
+func renderDataTable(w http.ResponseWriter, data []models.CompanyMetric, ltm map[string]models.CompanyMetric, restatements []database.Restatement,
+	companies []string, metric metrics.Definition, periodType string, theme string) {
+	companyData := make(map[string]map[string]models.CompanyMetric)
 	allQuarters := make(map[string]bool)
 
+	restated := make(map[string]database.Restatement, len(restatements))
+	for _, item := range restatements {
+		restated[fmt.Sprintf("%s %d-%s", item.Company, item.Year, item.Quarter)] = item
+	}
+
 	for _, item := range data {
 		key := fmt.Sprintf("%d-%s", item.Year, item.Quarter)
 		if companyData[item.Company] == nil {
-			companyData[item.Company] = make(map[string]float64)
+			companyData[item.Company] = make(map[string]models.CompanyMetric)
 		}
-		companyData[item.Company][key] = item.Value
+		companyData[item.Company][key] = item
 		allQuarters[key] = true
 	}
 
@@ -125,14 +151,7 @@ func renderDataTable(w http.ResponseWriter, data []database.CompanyMetric, compa
 	for q := range allQuarters {
 		quarters = append(quarters, q)
 	}
-	sort.Slice(quarters, func(i, j int) bool {
-		yearI, _ := strconv.Atoi(quarters[i][:4])
-		yearJ, _ := strconv.Atoi(quarters[j][:4])
-		if yearI == yearJ {
-			return quarters[i][5:] < quarters[j][5:]
-		}
-		return yearI < yearJ
-	})
+	sortPeriods(quarters)
 
 	bgPrimary := "#ffffff"
 	bgSecondary := "#f0f0f0"
@@ -193,6 +212,22 @@ func renderDataTable(w http.ResponseWriter, data []database.CompanyMetric, compa
 			font-style: italic;
 			text-align: center;
 		}
+		.data-table td.restated {
+			position: relative;
+		}
+		.data-table td.restated::after {
+			content: "";
+			position: absolute;
+			top: 0;
+			right: 0;
+			border-style: solid;
+			border-width: 0 8px 8px 0;
+			border-color: transparent #e67e22 transparent transparent;
+		}
+		.data-table td.restated a {
+			color: inherit;
+			text-decoration: none;
+		}
 		.table-container {
 			margin-top: 20px;
 			overflow-x: auto;
@@ -203,30 +238,55 @@ func renderDataTable(w http.ResponseWriter, data []database.CompanyMetric, compa
 		<table class="data-table">
 			<thead>
 				<tr>
-					<th>Company / Quarter</th>`,
+					<th>Company / Period</th>`,
 		bgPrimary, textPrimary, shadowColor, bgButton, borderColor, textPrimary, borderColor, textPrimary, bgSecondary, bgButtonHover)
 
 	for _, quarter := range quarters {
 		fmt.Fprintf(w, `<th>%s</th>`, quarter)
 	}
+	if len(ltm) > 0 {
+		fmt.Fprintf(w, `<th>LTM</th>`)
+	}
 
 	fmt.Fprintf(w, `</tr></thead><tbody>`)
 
 	for _, company := range companies {
-		if _, ok := companyData[company]; !ok {
+		_, hasQuarters := companyData[company]
+		_, hasLTM := ltm[company]
+		if !hasQuarters && !hasLTM {
 			continue
 		}
 
 		fmt.Fprintf(w, `<tr><td>%s</td>`, company)
 
 		for _, quarter := range quarters {
-			if val, ok := companyData[company][quarter]; ok && val != 0 {
-				unit := getMetricUnit(metric)
-				if unit != "" {
-					fmt.Fprintf(w, `<td>%.2f%s</td>`, val, unit)
+			if item, ok := companyData[company][quarter]; ok {
+				value := metric.Formatter.Cell(item.Value, metric.Unit)
+				tooltip := cellTooltip(item)
+				if restatement, ok := restated[company+" "+quarter]; ok {
+					tooltip = strings.TrimPrefix(tooltip+"\n"+restatementTooltip(restatement), "\n")
+					historyURL := "/api/financial-history?" + url.Values{
+						"company":     {company},
+						"period":      {quarter},
+						"period_type": {periodType},
+						"metric":      {metric.Key},
+					}.Encode()
+					fmt.Fprintf(w, `<td class="restated" title="%s"><a href="%s" target="_blank">%s</a></td>`,
+						html.EscapeString(tooltip), html.EscapeString(historyURL), value)
 				} else {
-					fmt.Fprintf(w, `<td>%.2f</td>`, val)
+					fmt.Fprintf(w, `<td title="%s">%s</td>`, html.EscapeString(tooltip), value)
+				}
+			} else {
+				fmt.Fprintf(w, `<td class="no-data">—</td>`)
+			}
+		}
+		if len(ltm) > 0 {
+			if item, ok := ltm[company]; ok {
+				tooltip := fmt.Sprintf("As of %d-%s", item.Year, item.Quarter)
+				if details := cellTooltip(item); details != "" {
+					tooltip += "\n" + details
 				}
+				fmt.Fprintf(w, `<td title="%s">%s</td>`, html.EscapeString(tooltip), metric.Formatter.Cell(item.Value, metric.Unit))
 			} else {
 				fmt.Fprintf(w, `<td class="no-data">—</td>`)
 			}
@@ -237,20 +297,63 @@ func renderDataTable(w http.ResponseWriter, data []database.CompanyMetric, compa
 	fmt.Fprintf(w, `</tbody></table></div>`)
 }
 
-func createNormalizedLineChart(data []database.CompanyMetric, metric string, companies []string, companyColors map[string]string) *charts.Line {
+func renderWarnings(w http.ResponseWriter, warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, `<div class="warnings" style="margin-top: 20px; padding: 10px 14px; border-left: 4px solid #e67e22; font-size: 13px;">
+		<strong>Some values are not shown:</strong><ul style="margin: 6px 0 0; padding-left: 20px;">`)
+	for _, warning := range warnings {
+		fmt.Fprintf(w, `<li>%s</li>`, html.EscapeString(warning))
+	}
+	fmt.Fprintf(w, `</ul></div>`)
+}
+
+func cellTooltip(item models.CompanyMetric) string {
+	var lines []string
+	if item.ReportDate != nil {
+		lines = append(lines, "Report date: "+item.ReportDate.Format("2006-01-02"))
+	}
+	if item.Currency != "" {
+		lines = append(lines, "Currency: "+item.Currency)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func restatementTooltip(restatement database.Restatement) string {
+	times := "time"
+	if restatement.Count > 1 {
+		times = "times"
+	}
+	return fmt.Sprintf("Restated %d %s, last on %s (click for history)", restatement.Count, times, restatement.LastChangedAt.Format("2006-01-02"))
+}
+
+func createNormalizedLineChart(data []models.CompanyMetric, query metricQuery, companyColors map[string]string) *charts.Line {
 	line := charts.NewLine()
 
-	metricName := formatMetricName(metric)
+	metric := query.metric
+	companies := query.companies
+	metricName := metric.DisplayName
 
 	yAxisName := metricName
-	unit := getMetricUnit(metric)
+	unit := metric.Unit
+
+	subtitle := query.transform.subtitle(query.periodType)
+	if query.rolling == rollingTTM {
+		subtitle += ", trailing twelve months"
+	}
+	if query.currency != "" && metric.Kind == metrics.KindMoney {
+		subtitle = fmt.Sprintf("%s, %s", subtitle, query.currency)
+		yAxisName = fmt.Sprintf("%s (%s)", metricName, query.currency)
+	}
 
-	tooltipFormatter := getTooltipFormatter(metric, unit)
+	tooltipFormatter := metric.Formatter.Tooltip(unit)
 
 	line.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
 			Title:    fmt.Sprintf("%s Comparison", metricName),
-			Subtitle: "Absolute values",
+			Subtitle: subtitle,
 			Left:     "center",
 		}),
 		charts.WithInitializationOpts(opts.Initialization{
@@ -279,7 +382,7 @@ func createNormalizedLineChart(data []database.CompanyMetric, metric string, com
 			ContainLabel: opts.Bool(true),
 		}),
 		charts.WithXAxisOpts(opts.XAxis{
-			Name:         "Quarter",
+			Name:         "Period",
 			NameLocation: "center",
 			NameGap:      30,
 			Type:         "category",
@@ -333,14 +436,7 @@ func createNormalizedLineChart(data []database.CompanyMetric, metric string, com
 	for q := range allQuarters {
 		quarters = append(quarters, q)
 	}
-	sort.Slice(quarters, func(i, j int) bool {
-		yearI, _ := strconv.Atoi(quarters[i][:4])
-		yearJ, _ := strconv.Atoi(quarters[j][:4])
-		if yearI == yearJ {
-			return quarters[i][5:] < quarters[j][5:]
-		}
-		return yearI < yearJ
-	})
+	sortPeriods(quarters)
 
 	line.SetXAxis(quarters)
 
@@ -354,7 +450,7 @@ func createNormalizedLineChart(data []database.CompanyMetric, metric string, com
 			values := make([]opts.LineData, len(quarters))
 
 			for i, q := range quarters {
-				if val, ok := companyValues[q]; ok && val != 0 {
+				if val, ok := companyValues[q]; ok {
 					values[i] = opts.LineData{
 						Value:      val,
 						Symbol:     "circle",
@@ -396,108 +492,3 @@ func createNormalizedLineChart(data []database.CompanyMetric, metric string, com
 
 	return line
 }
-
-func getTooltipFormatter(metric, unit string) string {
-	switch metric {
-	case "capitalization", "revenue", "net_profit", "ebitda", "debt", "capex", "opex":
-		return `
-            function(params) {
-                let result = params[0].name + '<br/>';
-                for(let i = 0; i < params.length; i++) {
-                    if (params[i].value !== null && params[i].value !== undefined) {
-                        let value = params[i].value;
-                        let formattedValue;
-                        if (value >= 1000000000000) {
-                            formattedValue = (value / 1000000000000).toFixed(2) + 'T';
-                        } else if (value >= 1000000000) {
-                            formattedValue = (value / 1000000000).toFixed(2) + 'B';
-                        } else if (value >= 1000000) {
-                            formattedValue = (value / 1000000).toFixed(2) + 'M';
-                        } else if (value >= 1000) {
-                            formattedValue = (value / 1000).toFixed(2) + 'K';
-                        } else {
-                            formattedValue = value.toFixed(2);
-                        }
-                        result += params[i].marker + ' ' + 
-                                params[i].seriesName + ': ' + 
-                                formattedValue + '` + unit + `' + '<br/>';
-                    } else {
-                        result += params[i].marker + ' ' + 
-                                params[i].seriesName + ': No data<br/>';
-                    }
-                }
-                return result;
-            }
-        `
-	case "pe", "ps":
-		return `
-            function(params) {
-                let result = params[0].name + '<br/>';
-                for(let i = 0; i < params.length; i++) {
-                    if (params[i].value !== null && params[i].value !== undefined) {
-                        result += params[i].marker + ' ' + 
-                                params[i].seriesName + ': ' + 
-                                params[i].value.toFixed(2) + '<br/>';
-                    } else {
-                        result += params[i].marker + ' ' + 
-                                params[i].seriesName + ': No data<br/>';
-                    }
-                }
-                return result;
-            }
-        `
-	default:
-		return `
-            function(params) {
-                let result = params[0].name + '<br/>';
-                for(let i = 0; i < params.length; i++) {
-                    if (params[i].value !== null && params[i].value !== undefined) {
-                        result += params[i].marker + ' ' + 
-                                params[i].seriesName + ': ' + 
-                                params[i].value.toFixed(2) + '` + unit + `' + '<br/>';
-                    } else {
-                        result += params[i].marker + ' ' + 
-                                params[i].seriesName + ': No data<br/>';
-                    }
-                }
-                return result;
-            }
-        `
-	}
-}
-
-func formatMetricName(metric string) string {
-	switch metric {
-	case "revenue":
-		return "Revenue"
-	case "net_profit":
-		return "Net Profit"
-	case "ebitda":
-		return "EBITDA"
-	case "pe":
-		return "P/E Ratio"
-	case "ps":
-		return "P/S Ratio"
-	case "roe":
-		return "ROE (%)"
-	case "roa":
-		return "ROA (%)"
-	case "dividends":
-		return "Dividends (%)"
-	case "capitalization":
-		return "Market Cap"
-	case "debt":
-		return "Debt"
-	default:
-		return metric
-	}
-}
-
-func getMetricUnit(metric string) string {
-	switch metric {
-	case "roe", "roa", "dividends":
-		return "%"
-	default:
-		return ""
-	}
-}
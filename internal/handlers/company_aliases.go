@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type CompanyAliasRequest struct {
+	Alias   string `json:"alias"`
+	Company string `json:"company"`
+}
+
+func (controller *Controller) GetCompanyAliases(w http.ResponseWriter, r *http.Request) {
+	aliases, err := controller.repo.GetCompanyAliases(r.Context(), r.URL.Query().Get("company"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(aliases)
+}
+
+func (controller *Controller) SaveCompanyAlias(w http.ResponseWriter, r *http.Request) {
+	var req CompanyAliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Alias = strings.TrimSpace(req.Alias)
+	req.Company = strings.TrimSpace(req.Company)
+	if req.Alias == "" || req.Company == "" {
+		http.Error(w, "Alias and company are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := controller.repo.SaveCompanyAlias(r.Context(), req.Alias, req.Company); err != nil {
+		writeCompanyError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Alias saved successfully",
+	})
+}
+
+func (controller *Controller) DeleteCompanyAlias(w http.ResponseWriter, r *http.Request) {
+	alias := strings.TrimSpace(r.URL.Query().Get("alias"))
+	if alias == "" {
+		http.Error(w, "Alias parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := controller.repo.DeleteCompanyAlias(r.Context(), alias); err != nil {
+		writeCompanyError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Alias deleted successfully",
+	})
+}
@@ -35,8 +35,12 @@ func (controller *Controller) SaveCompanyColor(w http.ResponseWriter, r *http.Re
 		req.Color = "#000000"
 	}
 
-	err := controller.repo.SaveCompanyColor(req.Company, req.Color)
+	err := controller.repo.SaveCompanyColor(r.Context(), req.Company, req.Color)
 	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -59,7 +63,7 @@ func (controller *Controller) DeleteCompanyColor(w http.ResponseWriter, r *http.
 		return
 	}
 
-	err := controller.repo.DeleteCompanyColor(company)
+	err := controller.repo.DeleteCompanyColor(r.Context(), company)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -83,7 +87,7 @@ func (controller *Controller) GetCompaniesColors(w http.ResponseWriter, r *http.
 
 	category := r.URL.Query().Get("category")
 
-	colors, err := controller.repo.GetCompaniesColorsByCategory(category)
+	colors, err := controller.repo.GetCompaniesColorsByCategory(r.Context(), category)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -1,13 +1,24 @@
 package handlers
 
-import "github.com/VxVxN/financialanalyzer/internal/database"
+import (
+	"log/slog"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+)
 
 type Controller struct {
-	repo *database.Repository
+	repo            database.Store
+	defaultCurrency string
+	deleteRetention time.Duration
+	logger          *slog.Logger
 }
 
-func NewController(repo *database.Repository) *Controller {
+func NewController(repo database.Store, defaultCurrency string, deleteRetention time.Duration, logger *slog.Logger) *Controller {
 	return &Controller{
-		repo: repo,
+		repo:            repo,
+		defaultCurrency: defaultCurrency,
+		deleteRetention: deleteRetention,
+		logger:          logger,
 	}
 }
@@ -4,12 +4,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type DeleteCompanyRequest struct {
 	Company string `json:"company"`
 }
 
+type DeleteCompanyResponse struct {
+	Message      string    `json:"message"`
+	Company      string    `json:"company"`
+	RestoreUntil time.Time `json:"restore_until"`
+}
+
 func (controller *Controller) DeleteCompany(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -28,7 +35,7 @@ func (controller *Controller) DeleteCompany(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err := controller.repo.DeleteCompany(req.Company)
+	deletedAt, err := controller.repo.DeleteCompany(r.Context(), req.Company)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			http.Error(w, err.Error(), http.StatusNotFound)
@@ -38,11 +45,39 @@ func (controller *Controller) DeleteCompany(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	controller.repo.DeleteCompanyNote(req.Company)
-	controller.repo.DeleteCompanyColor(req.Company)
-
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(DeleteCompanyResponse{
+		Message:      "Company deleted successfully",
+		Company:      req.Company,
+		RestoreUntil: deletedAt.Add(controller.deleteRetention),
+	})
+}
+
+func (controller *Controller) RestoreCompany(w http.ResponseWriter, r *http.Request) {
+	var req DeleteCompanyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Company = strings.TrimSpace(req.Company)
+	if req.Company == "" {
+		http.Error(w, "Company name is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := controller.repo.RestoreCompany(r.Context(), req.Company, controller.deleteRetention); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Company deleted successfully",
+		"message": "Company restored successfully",
 	})
 }
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+type derivedPoint struct {
+	item   models.CompanyMetric
+	inputs map[string]float64
+}
+
+// companiesMetric loads a stored metric or computes a derived one. A derived value exists only for the
+// periods in which every input is stored and the expression is defined, e.g. no division by zero.
+// With ttm the values are trailing twelve month values; a derived metric is computed from the
+// trailing values of its inputs, so a TTM margin is TTM profit over TTM revenue.
+func (controller *Controller) companiesMetric(ctx context.Context, companies []string, def metrics.Definition, periodType string, ttm bool) ([]models.CompanyMetric, error) {
+	if !def.Derived() {
+		return controller.storedMetric(ctx, companies, def, periodType, ttm)
+	}
+
+	var order []companyPeriod
+	points := make(map[companyPeriod]*derivedPoint)
+	for _, input := range def.Expression.Inputs() {
+		inputDef, ok := metrics.Lookup(input)
+		if !ok {
+			return nil, fmt.Errorf("unknown metric %s", input)
+		}
+		data, err := controller.storedMetric(ctx, companies, inputDef, periodType, ttm)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range data {
+			key := companyPeriod{company: item.Company, year: item.Year, quarter: item.Quarter}
+			point, ok := points[key]
+			if !ok {
+				point = &derivedPoint{item: item, inputs: make(map[string]float64)}
+				points[key] = point
+				order = append(order, key)
+			}
+			point.inputs[input] = item.Value
+		}
+	}
+
+	var result []models.CompanyMetric
+	for _, key := range order {
+		point := points[key]
+		value, ok := def.Expression.Eval(point.inputs)
+		if !ok {
+			continue
+		}
+		item := point.item
+		item.Value = value
+		result = append(result, item)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		if a.Quarter != b.Quarter {
+			return models.PeriodRank(a.Quarter) < models.PeriodRank(b.Quarter)
+		}
+		return a.Company < b.Company
+	})
+
+	return result, nil
+}
+
+// latestLTM returns the latest LTM value of every company, computing derived metrics like companiesMetric.
+func (controller *Controller) latestLTM(ctx context.Context, companies []string, def metrics.Definition) ([]models.CompanyMetric, error) {
+	if !def.Derived() {
+		return controller.repo.GetLatestLTM(ctx, companies, def.Key)
+	}
+
+	data, err := controller.companiesMetric(ctx, companies, def, models.PeriodLTM, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// data is ordered by period, so the last value of a company is its latest.
+	latest := make(map[string]models.CompanyMetric)
+	for _, item := range data {
+		latest[item.Company] = item
+	}
+
+	result := make([]models.CompanyMetric, 0, len(latest))
+	for _, item := range latest {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Company < result[j].Company })
+
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/VxVxN/financialanalyzer/internal/database"
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+type FinancialHistoryResponse struct {
+	Company    string                     `json:"company"`
+	Period     string                     `json:"period"`
+	PeriodType string                     `json:"period_type"`
+	Changes    []database.FinancialChange `json:"changes"`
+}
+
+func (controller *Controller) GetFinancialHistory(w http.ResponseWriter, r *http.Request) {
+	company := r.URL.Query().Get("company")
+	if company == "" {
+		http.Error(w, "Company parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	period, err := models.ParsePeriod(r.URL.Query().Get("period"))
+	if err != nil {
+		http.Error(w, "Invalid period parameter", http.StatusBadRequest)
+		return
+	}
+
+	periodType := period.Type
+	switch requested := r.URL.Query().Get("period_type"); requested {
+	case "", period.Type:
+	case models.PeriodLTM:
+		// LTM values are anchored to the latest quarter of a file, or its latest half-year or year without quarters.
+		periodType = models.PeriodLTM
+	default:
+		http.Error(w, "Invalid period_type parameter", http.StatusBadRequest)
+		return
+	}
+
+	metric := r.URL.Query().Get("metric")
+	if metric != "" {
+		if _, ok := metrics.Lookup(metric); !ok {
+			http.Error(w, "Unknown metric", http.StatusNotFound)
+			return
+		}
+	}
+
+	changes, err := controller.repo.GetFinancialHistory(r.Context(), company, period.Year, period.Label, periodType, metric)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(FinancialHistoryResponse{
+		Company:    company,
+		Period:     period.String(),
+		PeriodType: periodType,
+		Changes:    changes,
+	})
+}
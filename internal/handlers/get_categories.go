@@ -6,7 +6,7 @@ import (
 )
 
 func (controller *Controller) GetCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := controller.repo.GetAllCategories()
+	categories, err := controller.repo.GetAllCategories(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -6,7 +6,18 @@ import (
 )
 
 func (controller *Controller) GetCompanies(w http.ResponseWriter, r *http.Request) {
-	companies, err := controller.repo.GetAllCompanies()
+	companies, err := controller.repo.GetAllCompanies(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(companies)
+}
+
+func (controller *Controller) GetCompanyDetails(w http.ResponseWriter, r *http.Request) {
+	companies, err := controller.repo.GetCompanies(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -11,7 +11,7 @@ type CompanyWithCategory struct {
 }
 
 func (controller *Controller) GetCompaniesWithCategories(w http.ResponseWriter, r *http.Request) {
-	companies, err := controller.repo.GetAllCompaniesWithCategories()
+	companies, err := controller.repo.GetAllCompaniesWithCategories(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -3,8 +3,15 @@ package handlers
 import (
 	"html/template"
 	"net/http"
+
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
 )
 
+type MetricOption struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
 func (controller *Controller) IndexHandler(w http.ResponseWriter, r *http.Request) {
 	tmpl, err := template.ParseFiles("templates/index.html")
 	if err != nil {
@@ -12,12 +19,15 @@ func (controller *Controller) IndexHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	metrics := []string{"revenue", "net_profit", "ebitda", "pe", "ps", "roe", "roa", "capitalization", "debt", "capex", "opex", "dividends"}
+	var options []MetricOption
+	for _, def := range metrics.All() {
+		options = append(options, MetricOption{Key: def.Key, Name: def.DisplayName})
+	}
 
 	data := struct {
-		Metrics []string
+		Metrics []MetricOption
 	}{
-		Metrics: metrics,
+		Metrics: options,
 	}
 
 	w.Header().Set("Content-Type", "text/html")
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+type MetricPoint struct {
+	Period     string     `json:"period"`
+	Year       int        `json:"year"`
+	Quarter    string     `json:"quarter"`
+	Value      float64    `json:"value"`
+	ReportDate *time.Time `json:"report_date,omitempty"`
+	Currency   string     `json:"currency,omitempty"`
+}
+
+type MetricSeries struct {
+	Company string        `json:"company"`
+	Points  []MetricPoint `json:"points"`
+	LTM     *MetricPoint  `json:"ltm,omitempty"`
+}
+
+type MetricResponse struct {
+	Metric   string         `json:"metric"`
+	Name     string         `json:"name"`
+	Unit     string         `json:"unit"`
+	Kind     string         `json:"kind"`
+	Currency string         `json:"currency,omitempty"`
+	Period   string         `json:"period"`
+	Rolling  string         `json:"rolling,omitempty"`
+	Series   []MetricSeries `json:"series"`
+	Warnings []string       `json:"warnings,omitempty"`
+}
+
+func (controller *Controller) GetMetric(w http.ResponseWriter, r *http.Request) {
+	query, err := controller.parseMetricQuery(r)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	data, warnings, err := controller.loadMetricData(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ltm, ltmWarnings, err := controller.loadLTM(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	warnings = append(warnings, ltmWarnings...)
+
+	series := make(map[string]*MetricSeries)
+	for _, item := range data {
+		if series[item.Company] == nil {
+			series[item.Company] = &MetricSeries{Company: item.Company, Points: []MetricPoint{}}
+		}
+		series[item.Company].Points = append(series[item.Company].Points, newMetricPoint(item))
+	}
+	for company, item := range ltm {
+		if series[company] == nil {
+			series[company] = &MetricSeries{Company: company, Points: []MetricPoint{}}
+		}
+		point := newMetricPoint(item)
+		point.Period = "LTM"
+		series[company].LTM = &point
+	}
+
+	response := MetricResponse{
+		Metric:   query.metric.Key,
+		Name:     query.metric.DisplayName,
+		Unit:     query.metric.Unit,
+		Kind:     string(query.metric.Kind),
+		Currency: query.currency,
+		Period:   query.periodType,
+		Rolling:  query.rolling,
+		Series:   make([]MetricSeries, 0, len(series)),
+		Warnings: warnings,
+	}
+	for _, company := range query.companies {
+		if companySeries, ok := series[company]; ok {
+			response.Series = append(response.Series, *companySeries)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func newMetricPoint(item models.CompanyMetric) MetricPoint {
+	return MetricPoint{
+		Period:     fmt.Sprintf("%d-%s", item.Year, item.Quarter),
+		Year:       item.Year,
+		Quarter:    item.Quarter,
+		Value:      item.Value,
+		ReportDate: item.ReportDate,
+		Currency:   item.Currency,
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/VxVxN/financialanalyzer/internal/fx"
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+type metricQuery struct {
+	metric     metrics.Definition
+	companies  []string
+	currency   string
+	periodType string
+	rolling    string
+	transform  chartTransform
+}
+
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func (controller *Controller) parseMetricQuery(r *http.Request) (metricQuery, error) {
+	var query metricQuery
+
+	metric, ok := metrics.Lookup(chi.URLParam(r, "metric"))
+	if !ok {
+		return query, &requestError{status: http.StatusNotFound, message: "Unknown metric"}
+	}
+	query.metric = metric
+
+	query.currency = strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("currency")))
+	if query.currency != "" && !fx.ValidCurrency(query.currency) {
+		return query, &requestError{status: http.StatusBadRequest, message: "Invalid currency parameter"}
+	}
+
+	switch period := r.URL.Query().Get("period"); period {
+	case "":
+		query.periodType = models.PeriodQuarter
+	case models.PeriodQuarter, models.PeriodHalf, models.PeriodYear:
+		query.periodType = period
+	default:
+		return query, &requestError{status: http.StatusBadRequest, message: "Invalid period parameter"}
+	}
+
+	switch rolling := r.URL.Query().Get("rolling"); rolling {
+	case "":
+	case rollingTTM:
+		if query.periodType != models.PeriodQuarter {
+			return query, &requestError{status: http.StatusBadRequest, message: "Rolling aggregation needs quarterly data"}
+		}
+		query.rolling = rolling
+	default:
+		return query, &requestError{status: http.StatusBadRequest, message: "Invalid rolling parameter"}
+	}
+
+	if companiesParam := r.URL.Query().Get("companies"); companiesParam != "" {
+		query.companies = strings.Split(companiesParam, ",")
+	} else {
+		companies, err := controller.repo.GetAllCompanies(r.Context())
+		if err != nil {
+			return query, err
+		}
+		query.companies = companies
+	}
+
+	return query, nil
+}
+
+func writeQueryError(w http.ResponseWriter, err error) {
+	if reqErr, ok := err.(*requestError); ok {
+		http.Error(w, reqErr.message, reqErr.status)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// loadMetricData returns the values of the query and warnings about values left out for lack of an FX rate.
+func (controller *Controller) loadMetricData(ctx context.Context, query metricQuery) ([]models.CompanyMetric, []string, error) {
+	data, err := controller.companiesMetric(ctx, query.companies, query.metric, query.periodType, query.rolling == rollingTTM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if query.currency == "" || query.metric.Kind != metrics.KindMoney {
+		return data, nil, nil
+	}
+
+	return controller.convertCurrency(ctx, data, query.currency)
+}
+
+func (controller *Controller) loadLTM(ctx context.Context, query metricQuery) (map[string]models.CompanyMetric, []string, error) {
+	data, err := controller.latestLTM(ctx, query.companies, query.metric)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var warnings []string
+	if query.currency != "" && query.metric.Kind == metrics.KindMoney {
+		data, warnings, err = controller.convertCurrency(ctx, data, query.currency)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ltm := make(map[string]models.CompanyMetric, len(data))
+	for _, item := range data {
+		ltm[item.Company] = item
+	}
+
+	return ltm, warnings, nil
+}
+
+// convertCurrency converts data to currency. A value without a rate for its period cannot be shown
+// in currency, so it is left out and reported in a warning instead of vanishing silently.
+func (controller *Controller) convertCurrency(ctx context.Context, data []models.CompanyMetric, currency string) ([]models.CompanyMetric, []string, error) {
+	currencies := []string{currency}
+	seen := map[string]bool{currency: true}
+	for _, item := range data {
+		from := controller.reportingCurrency(item)
+		if !seen[from] {
+			seen[from] = true
+			currencies = append(currencies, from)
+		}
+	}
+
+	rates, err := controller.repo.GetFXRates(ctx, currencies)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load fx rates: %w", err)
+	}
+	table := fx.NewTable(rates)
+
+	converted := make([]models.CompanyMetric, 0, len(data))
+	var warnings []string
+	for _, item := range data {
+		from := controller.reportingCurrency(item)
+		value, ok := table.Convert(item.Value, from, currency, item.PeriodEnd())
+		if !ok {
+			missing := currency
+			if _, ok := table.RateOn(from, item.PeriodEnd()); !ok {
+				missing = from
+			}
+			date := item.PeriodEnd().Format("2006-01-02")
+			warnings = append(warnings, fmt.Sprintf("%s %d-%s: no %s rate on or before %s", item.Company, item.Year, item.Quarter, missing, date))
+			controller.logger.Warn("Missing FX rate", "company", item.Company, "period", fmt.Sprintf("%d-%s", item.Year, item.Quarter),
+				"currency", missing, "date", date)
+			continue
+		}
+		item.Value = value
+		item.Currency = currency
+		converted = append(converted, item)
+	}
+
+	return converted, warnings, nil
+}
+
+func sortPeriods(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		periodI, _ := models.ParsePeriod(keys[i])
+		periodJ, _ := models.ParsePeriod(keys[j])
+		return periodI.Before(periodJ)
+	})
+}
+
+func (controller *Controller) reportingCurrency(item models.CompanyMetric) string {
+	if item.Currency != "" {
+		return item.Currency
+	}
+	return controller.defaultCurrency
+}
@@ -29,7 +29,7 @@ func (controller *Controller) GetCompanyNote(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	note, err := controller.repo.GetCompanyNote(company)
+	note, err := controller.repo.GetCompanyNote(r.Context(), company)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -60,8 +60,12 @@ func (controller *Controller) SaveCompanyNote(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	err := controller.repo.SaveCompanyNote(req.Company, req.Note)
+	err := controller.repo.SaveCompanyNote(r.Context(), req.Company, req.Note)
 	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -85,7 +89,7 @@ func (controller *Controller) DeleteCompanyNote(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err := controller.repo.DeleteCompanyNote(company)
+	err := controller.repo.DeleteCompanyNote(r.Context(), company)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
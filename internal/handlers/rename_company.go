@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type RenameCompanyRequest struct {
+	Company string `json:"company"`
+	NewName string `json:"new_name"`
+}
+
+type MergeCompaniesRequest struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+func (controller *Controller) RenameCompany(w http.ResponseWriter, r *http.Request) {
+	var req RenameCompanyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Company = strings.TrimSpace(req.Company)
+	req.NewName = strings.TrimSpace(req.NewName)
+	if req.Company == "" || req.NewName == "" {
+		http.Error(w, "Company and new name are required", http.StatusBadRequest)
+		return
+	}
+	if req.Company == req.NewName {
+		http.Error(w, "New name must differ from the current one", http.StatusBadRequest)
+		return
+	}
+
+	if err := controller.repo.RenameCompany(r.Context(), req.Company, req.NewName); err != nil {
+		writeCompanyError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Company renamed successfully",
+		"company": req.NewName,
+	})
+}
+
+func (controller *Controller) MergeCompanies(w http.ResponseWriter, r *http.Request) {
+	var req MergeCompaniesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Source = strings.TrimSpace(req.Source)
+	req.Target = strings.TrimSpace(req.Target)
+	if req.Source == "" || req.Target == "" {
+		http.Error(w, "Source and target companies are required", http.StatusBadRequest)
+		return
+	}
+	if req.Source == req.Target {
+		http.Error(w, "Cannot merge a company into itself", http.StatusBadRequest)
+		return
+	}
+
+	if err := controller.repo.MergeCompanies(r.Context(), req.Source, req.Target); err != nil {
+		writeCompanyError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Companies merged successfully",
+		"company": req.Target,
+	})
+}
+
+func writeCompanyError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "already exists"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+// rollingTTM rolls quarterly values up to trailing twelve months.
+const rollingTTM = "ttm"
+
+func (controller *Controller) storedMetric(ctx context.Context, companies []string, def metrics.Definition, periodType string, ttm bool) ([]models.CompanyMetric, error) {
+	data, err := controller.repo.GetCompaniesMetric(ctx, companies, def.Key, periodType)
+	if err != nil || !ttm {
+		return data, err
+	}
+	return rollTTM(data, def.Aggregation), nil
+}
+
+// rollTTM aggregates every quarter with the three quarters before it. A quarter gets a value only
+// when all four quarters are present and reported in the same currency, so a gap never produces a
+// partial sum; the first three quarters of a series have no value either.
+func rollTTM(data []models.CompanyMetric, aggregation metrics.Aggregation) []models.CompanyMetric {
+	quarters := make(map[companyPeriod]models.CompanyMetric, len(data))
+	for _, item := range data {
+		quarters[companyPeriod{company: item.Company, year: item.Year, quarter: item.Quarter}] = item
+	}
+
+	var result []models.CompanyMetric
+	for _, item := range data {
+		values := make([]float64, 4)
+		values[3] = item.Value
+
+		year, quarter := item.Year, item.Quarter
+		complete := true
+		for i := 2; i >= 0; i-- {
+			year, quarter = models.PreviousPeriod(year, quarter)
+			previous, ok := quarters[companyPeriod{company: item.Company, year: year, quarter: quarter}]
+			if !ok || previous.Currency != item.Currency {
+				complete = false
+				break
+			}
+			values[i] = previous.Value
+		}
+		if !complete {
+			continue
+		}
+
+		item.Value = aggregation.Apply(values)
+		result = append(result, item)
+	}
+
+	return result
+}
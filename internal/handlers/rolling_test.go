@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+func TestRollTTM(t *testing.T) {
+	year := []models.CompanyMetric{
+		metric("A", 2022, "Q1", 1),
+		metric("A", 2022, "Q2", 2),
+		metric("A", 2022, "Q3", 3),
+		metric("A", 2022, "Q4", 4),
+		metric("A", 2023, "Q1", 5),
+	}
+
+	tests := []struct {
+		name        string
+		data        []models.CompanyMetric
+		aggregation metrics.Aggregation
+		want        []models.CompanyMetric
+	}{
+		{
+			name:        "sum of four consecutive quarters",
+			data:        year,
+			aggregation: metrics.AggregationSum,
+			want:        []models.CompanyMetric{metric("A", 2022, "Q4", 10), metric("A", 2023, "Q1", 14)},
+		},
+		{
+			name:        "average of four consecutive quarters",
+			data:        year,
+			aggregation: metrics.AggregationAvg,
+			want:        []models.CompanyMetric{metric("A", 2022, "Q4", 2.5), metric("A", 2023, "Q1", 3.5)},
+		},
+		{
+			name:        "last of four consecutive quarters",
+			data:        year,
+			aggregation: metrics.AggregationLast,
+			want:        []models.CompanyMetric{metric("A", 2022, "Q4", 4), metric("A", 2023, "Q1", 5)},
+		},
+		{
+			name:        "fewer than four quarters",
+			data:        year[:3],
+			aggregation: metrics.AggregationSum,
+			want:        nil,
+		},
+		{
+			name: "gap across the year boundary",
+			data: []models.CompanyMetric{
+				metric("A", 2022, "Q1", 1),
+				metric("A", 2022, "Q2", 2),
+				metric("A", 2022, "Q3", 3),
+				metric("A", 2023, "Q1", 5),
+				metric("A", 2023, "Q2", 6),
+				metric("A", 2023, "Q3", 7),
+				metric("A", 2023, "Q4", 8),
+			},
+			aggregation: metrics.AggregationSum,
+			want:        []models.CompanyMetric{metric("A", 2023, "Q4", 26)},
+		},
+		{
+			name: "mixed currencies",
+			data: []models.CompanyMetric{
+				metric("A", 2022, "Q1", 1),
+				withCurrency(metric("A", 2022, "Q2", 2), "USD"),
+				metric("A", 2022, "Q3", 3),
+				metric("A", 2022, "Q4", 4),
+				metric("A", 2023, "Q1", 5),
+				metric("A", 2023, "Q2", 6),
+			},
+			aggregation: metrics.AggregationSum,
+			want:        []models.CompanyMetric{metric("A", 2023, "Q2", 18)},
+		},
+		{
+			name: "quarters of another company",
+			data: []models.CompanyMetric{
+				metric("A", 2022, "Q1", 1),
+				metric("B", 2022, "Q2", 2),
+				metric("A", 2022, "Q3", 3),
+				metric("A", 2022, "Q4", 4),
+			},
+			aggregation: metrics.AggregationSum,
+			want:        nil,
+		},
+	}
+
+	for _, tt := range tests {
+		got := rollTTM(tt.data, tt.aggregation)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: rollTTM = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+type chartTransform string
+
+const (
+	transformNone       chartTransform = ""
+	transformYoY        chartTransform = "yoy"
+	transformQoQ        chartTransform = "qoq"
+	transformIndex      chartTransform = "index"
+	transformCumulative chartTransform = "cumulative"
+)
+
+func parseTransform(r *http.Request) (chartTransform, error) {
+	switch transform := chartTransform(r.URL.Query().Get("transform")); transform {
+	case transformNone, transformYoY, transformQoQ, transformIndex, transformCumulative:
+		return transform, nil
+	default:
+		return transformNone, &requestError{status: http.StatusBadRequest, message: "Invalid transform parameter"}
+	}
+}
+
+// definition returns how values of metric read after the transform: growth rates are percentages
+// and an index has no unit, cumulative values keep the unit of the metric.
+func (t chartTransform) definition(metric metrics.Definition) metrics.Definition {
+	switch t {
+	case transformYoY, transformQoQ:
+		metric.Kind = metrics.KindPercent
+		metric.Unit = "%"
+	case transformIndex:
+		metric.Kind = metrics.KindRatio
+		metric.Unit = ""
+	default:
+		return metric
+	}
+	metric.Formatter = metrics.FormatterForKind(metric.Kind)
+	return metric
+}
+
+func (t chartTransform) subtitle(periodType string) string {
+	switch t {
+	case transformYoY:
+		return "Year-over-year growth, %"
+	case transformQoQ:
+		if periodType != models.PeriodQuarter {
+			return "Period-over-period growth, %"
+		}
+		return "Quarter-over-quarter growth, %"
+	case transformIndex:
+		return "Index, first common period = 100"
+	case transformCumulative:
+		return "Cumulative values"
+	default:
+		return "Absolute values"
+	}
+}
+
+// apply transforms the series of every company. Growth is measured against the absolute value of
+// the base, so a loss shrinking from -10 to -5 reads as +50%; points without a non-zero base in the
+// same currency are dropped.
+func (t chartTransform) apply(data []models.CompanyMetric) []models.CompanyMetric {
+	switch t {
+	case transformYoY:
+		return growth(data, func(year int, quarter string) (int, string) { return year - 1, quarter })
+	case transformQoQ:
+		return growth(data, models.PreviousPeriod)
+	case transformIndex:
+		return indexToFirstCommon(data)
+	case transformCumulative:
+		return cumulative(data)
+	default:
+		return data
+	}
+}
+
+type companyPeriod struct {
+	company string
+	year    int
+	quarter string
+}
+
+func growth(data []models.CompanyMetric, base func(year int, quarter string) (int, string)) []models.CompanyMetric {
+	values := make(map[companyPeriod]models.CompanyMetric, len(data))
+	for _, item := range data {
+		values[companyPeriod{company: item.Company, year: item.Year, quarter: item.Quarter}] = item
+	}
+
+	var result []models.CompanyMetric
+	for _, item := range data {
+		year, quarter := base(item.Year, item.Quarter)
+		previous, ok := values[companyPeriod{company: item.Company, year: year, quarter: quarter}]
+		if !ok || previous.Value == 0 || previous.Currency != item.Currency {
+			continue
+		}
+		item.Value = (item.Value - previous.Value) / math.Abs(previous.Value) * 100
+		result = append(result, item)
+	}
+
+	return result
+}
+
+// indexToFirstCommon rebases every company to 100 at the first period for which all of them have a
+// value. Companies with a non-positive base cannot be indexed and are dropped.
+func indexToFirstCommon(data []models.CompanyMetric) []models.CompanyMetric {
+	companies := make(map[string]bool)
+	periods := make(map[string]map[string]float64)
+	for _, item := range data {
+		companies[item.Company] = true
+		period := fmt.Sprintf("%d-%s", item.Year, item.Quarter)
+		if periods[period] == nil {
+			periods[period] = make(map[string]float64)
+		}
+		periods[period][item.Company] = item.Value
+	}
+
+	keys := make([]string, 0, len(periods))
+	for period := range periods {
+		keys = append(keys, period)
+	}
+	sortPeriods(keys)
+
+	var base map[string]float64
+	for _, period := range keys {
+		if len(periods[period]) == len(companies) {
+			base = periods[period]
+			break
+		}
+	}
+	if base == nil {
+		return nil
+	}
+
+	var result []models.CompanyMetric
+	for _, item := range data {
+		if base[item.Company] <= 0 {
+			continue
+		}
+		item.Value = item.Value / base[item.Company] * 100
+		result = append(result, item)
+	}
+
+	return result
+}
+
+func cumulative(data []models.CompanyMetric) []models.CompanyMetric {
+	result := make([]models.CompanyMetric, len(data))
+	copy(result, data)
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		return models.PeriodRank(a.Quarter) < models.PeriodRank(b.Quarter)
+	})
+
+	totals := make(map[string]float64)
+	for i := range result {
+		totals[result[i].Company] += result[i].Value
+		result[i].Value = totals[result[i].Company]
+	}
+
+	return result
+}
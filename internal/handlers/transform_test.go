@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+func metric(company string, year int, quarter string, value float64) models.CompanyMetric {
+	return models.CompanyMetric{Company: company, Year: year, Quarter: quarter, Value: value, Currency: "RUB"}
+}
+
+func withCurrency(item models.CompanyMetric, currency string) models.CompanyMetric {
+	item.Currency = currency
+	return item
+}
+
+func TestGrowth(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform chartTransform
+		data      []models.CompanyMetric
+		want      []models.CompanyMetric
+	}{
+		{
+			name:      "year over year",
+			transform: transformYoY,
+			data:      []models.CompanyMetric{metric("A", 2022, "Q1", 100), metric("A", 2022, "Q2", 50), metric("A", 2023, "Q1", 150)},
+			want:      []models.CompanyMetric{metric("A", 2023, "Q1", 50)},
+		},
+		{
+			name:      "quarter over quarter across the year boundary",
+			transform: transformQoQ,
+			data:      []models.CompanyMetric{metric("A", 2022, "Q4", 200), metric("A", 2023, "Q1", 150), metric("A", 2023, "Q2", 300)},
+			want:      []models.CompanyMetric{metric("A", 2023, "Q1", -25), metric("A", 2023, "Q2", 100)},
+		},
+		{
+			name:      "half-year over half-year",
+			transform: transformQoQ,
+			data:      []models.CompanyMetric{metric("A", 2022, "H2", 100), metric("A", 2023, "H1", 120)},
+			want:      []models.CompanyMetric{metric("A", 2023, "H1", 20)},
+		},
+		{
+			name:      "zero base",
+			transform: transformYoY,
+			data:      []models.CompanyMetric{metric("A", 2022, "FY", 0), metric("A", 2023, "FY", 10)},
+			want:      nil,
+		},
+		{
+			name:      "negative base",
+			transform: transformYoY,
+			data:      []models.CompanyMetric{metric("A", 2022, "FY", -10), metric("A", 2023, "FY", -5)},
+			want:      []models.CompanyMetric{metric("A", 2023, "FY", 50)},
+		},
+		{
+			name:      "missing prior period",
+			transform: transformQoQ,
+			data:      []models.CompanyMetric{metric("A", 2023, "Q1", 100), metric("A", 2023, "Q3", 120)},
+			want:      nil,
+		},
+		{
+			name:      "prior period of another company",
+			transform: transformYoY,
+			data:      []models.CompanyMetric{metric("A", 2022, "FY", 100), metric("B", 2023, "FY", 120)},
+			want:      nil,
+		},
+		{
+			name:      "currency change between periods",
+			transform: transformYoY,
+			data: []models.CompanyMetric{
+				metric("A", 2021, "FY", 100),
+				withCurrency(metric("A", 2022, "FY", 2), "USD"),
+				withCurrency(metric("A", 2023, "FY", 3), "USD"),
+			},
+			want: []models.CompanyMetric{withCurrency(metric("A", 2023, "FY", 50), "USD")},
+		},
+	}
+
+	for _, tt := range tests {
+		got := tt.transform.apply(tt.data)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: apply = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIndexToFirstCommon(t *testing.T) {
+	tests := []struct {
+		name string
+		data []models.CompanyMetric
+		want []models.CompanyMetric
+	}{
+		{
+			name: "rebased at the first common period",
+			data: []models.CompanyMetric{
+				metric("A", 2021, "FY", 10),
+				metric("A", 2022, "FY", 50),
+				metric("B", 2022, "FY", 200),
+				metric("A", 2023, "FY", 75),
+				metric("B", 2023, "FY", 100),
+			},
+			want: []models.CompanyMetric{
+				metric("A", 2021, "FY", 20),
+				metric("A", 2022, "FY", 100),
+				metric("B", 2022, "FY", 100),
+				metric("A", 2023, "FY", 150),
+				metric("B", 2023, "FY", 50),
+			},
+		},
+		{
+			name: "quarters ordered by period rather than by label",
+			data: []models.CompanyMetric{
+				metric("A", 2023, "Q2", 40),
+				metric("A", 2023, "Q1", 20),
+				metric("B", 2023, "Q2", 10),
+				metric("B", 2023, "Q1", 5),
+			},
+			want: []models.CompanyMetric{
+				metric("A", 2023, "Q2", 200),
+				metric("A", 2023, "Q1", 100),
+				metric("B", 2023, "Q2", 200),
+				metric("B", 2023, "Q1", 100),
+			},
+		},
+		{
+			name: "zero and negative bases are dropped",
+			data: []models.CompanyMetric{
+				metric("A", 2022, "FY", 10),
+				metric("B", 2022, "FY", 0),
+				metric("C", 2022, "FY", -5),
+				metric("A", 2023, "FY", 20),
+				metric("B", 2023, "FY", 5),
+				metric("C", 2023, "FY", 5),
+			},
+			want: []models.CompanyMetric{metric("A", 2022, "FY", 100), metric("A", 2023, "FY", 200)},
+		},
+		{
+			name: "no common first period",
+			data: []models.CompanyMetric{metric("A", 2022, "FY", 10), metric("B", 2023, "FY", 20)},
+			want: nil,
+		},
+		{
+			name: "no data",
+			data: nil,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		got := transformIndex.apply(tt.data)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: apply = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCumulative(t *testing.T) {
+	data := []models.CompanyMetric{
+		metric("B", 2023, "Q1", 5),
+		metric("A", 2023, "Q2", 20),
+		metric("A", 2023, "Q1", 10),
+		metric("A", 2022, "Q4", -5),
+		metric("B", 2023, "Q2", 5),
+	}
+	want := []models.CompanyMetric{
+		metric("A", 2022, "Q4", -5),
+		metric("B", 2023, "Q1", 5),
+		metric("A", 2023, "Q1", 5),
+		metric("A", 2023, "Q2", 25),
+		metric("B", 2023, "Q2", 10),
+	}
+
+	got := transformCumulative.apply(data)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("apply = %+v, want %+v", got, want)
+	}
+	if data[0].Value != 5 || data[1].Value != 20 {
+		t.Errorf("apply modified its input: %+v", data)
+	}
+}
+
+func TestParseTransform(t *testing.T) {
+	for _, query := range []string{"", "transform=yoy", "transform=qoq", "transform=index", "transform=cumulative"} {
+		if _, err := parseTransform(httptest.NewRequest("GET", "/chart?"+query, nil)); err != nil {
+			t.Errorf("parseTransform(%q): %v", query, err)
+		}
+	}
+	if _, err := parseTransform(httptest.NewRequest("GET", "/chart?transform=log", nil)); err == nil {
+		t.Error("parseTransform must reject an unknown transform")
+	}
+}
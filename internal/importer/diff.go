@@ -0,0 +1,188 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+type RowStatus string
+
+const (
+	RowNew       RowStatus = "new"
+	RowChanged   RowStatus = "changed"
+	RowUnchanged RowStatus = "unchanged"
+)
+
+type ValueChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+type RowDiff struct {
+	Company    string
+	Year       int
+	Quarter    string
+	PeriodType string
+	Status     RowStatus
+	Changes    []ValueChange
+}
+
+type DiffReport struct {
+	Rows []RowDiff
+}
+
+// Diff compares parsed rows with the rows currently stored, following the rules of
+// the import mode: in merge mode a missing parsed value keeps the stored one, in
+// replace mode it clears it.
+func Diff(parsed, existing []models.QuarterData, mode models.ImportMode) *DiffReport {
+	stored := make(map[rowKey]models.QuarterData, len(existing))
+	for _, item := range existing {
+		stored[keyOf(item)] = item
+	}
+
+	report := &DiffReport{}
+	for _, item := range Merge(parsed) {
+		row := RowDiff{
+			Company:    item.Company,
+			Year:       item.Year,
+			Quarter:    item.Quarter,
+			PeriodType: item.PeriodType,
+		}
+
+		old, found := stored[keyOf(item)]
+		row.Changes = append(row.Changes, attributeChanges(item, old, mode)...)
+		for _, def := range metrics.All() {
+			if def.Derived() {
+				continue
+			}
+			newValue := item.Value(def.Key)
+			if newValue.IsMissing() && mode != models.ImportModeReplace {
+				continue
+			}
+			newValue = models.NullFloat64{Float64: storedScale(newValue.Float64), Valid: newValue.Valid}
+
+			var oldValue models.NullFloat64
+			if found {
+				oldValue = old.Value(def.Key)
+			}
+			if oldValue != newValue {
+				row.Changes = append(row.Changes, ValueChange{
+					Field: def.Key,
+					Old:   formatDiffValue(oldValue),
+					New:   formatDiffValue(newValue),
+				})
+			}
+		}
+
+		switch {
+		case !found:
+			row.Status = RowNew
+		case len(row.Changes) > 0:
+			row.Status = RowChanged
+		default:
+			row.Status = RowUnchanged
+		}
+
+		report.Rows = append(report.Rows, row)
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.Company != b.Company {
+			return a.Company < b.Company
+		}
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		if a.Quarter != b.Quarter {
+			return models.PeriodRank(a.Quarter) < models.PeriodRank(b.Quarter)
+		}
+		return a.PeriodType > b.PeriodType
+	})
+
+	return report
+}
+
+func (r *DiffReport) Count(status RowStatus) int {
+	count := 0
+	for _, row := range r.Rows {
+		if row.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *DiffReport) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "COMPANY\tPERIOD\tSTATUS\tCHANGES")
+	for _, row := range r.Rows {
+		changes := make([]string, 0, len(row.Changes))
+		for _, change := range row.Changes {
+			changes = append(changes, fmt.Sprintf("%s: %s → %s", change.Field, change.Old, change.New))
+		}
+		period := fmt.Sprintf("%d-%s", row.Year, row.Quarter)
+		if row.PeriodType == models.PeriodLTM {
+			period += " LTM"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", row.Company, period, row.Status, strings.Join(changes, ", "))
+	}
+
+	fmt.Fprintf(tw, "\nnew: %d\tchanged: %d\tunchanged: %d\n",
+		r.Count(RowNew), r.Count(RowChanged), r.Count(RowUnchanged))
+
+	return tw.Flush()
+}
+
+func attributeChanges(item, old models.QuarterData, mode models.ImportMode) []ValueChange {
+	var changes []ValueChange
+
+	newDate, oldDate := formatDiffDate(item.ReportDate), formatDiffDate(old.ReportDate)
+	if newDate != oldDate && (!item.ReportDate.IsZero() || mode == models.ImportModeReplace) {
+		changes = append(changes, ValueChange{Field: "report_date", Old: oldDate, New: newDate})
+	}
+
+	newCurrency, oldCurrency := formatDiffText(item.Currency), formatDiffText(old.Currency)
+	if newCurrency != oldCurrency && (item.Currency != "" || mode == models.ImportModeReplace) {
+		changes = append(changes, ValueChange{Field: "currency", Old: oldCurrency, New: newCurrency})
+	}
+
+	return changes
+}
+
+func formatDiffDate(date time.Time) string {
+	if date.IsZero() {
+		return "—"
+	}
+	return date.Format("2006-01-02")
+}
+
+func formatDiffText(text string) string {
+	if text == "" {
+		return "—"
+	}
+	return text
+}
+
+// storedScale rounds a parsed value like the NUMERIC(20,2) column it is stored in, so values with
+// more decimals compare equal to what an identical import stored before.
+func storedScale(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func formatDiffValue(value models.NullFloat64) string {
+	if !value.Valid {
+		return "—"
+	}
+	return fmt.Sprintf("%.2f", value.Float64)
+}
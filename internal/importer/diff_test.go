@@ -0,0 +1,32 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+func TestDiffRoundsToStoredScale(t *testing.T) {
+	row := func(revenue float64) models.QuarterData {
+		data := models.QuarterData{Company: "AAA", Year: 2023, Quarter: "Q1", PeriodType: models.PeriodQuarter}
+		data.SetValue("revenue", models.Float(revenue))
+		return data
+	}
+
+	tests := []struct {
+		parsed, stored float64
+		want           RowStatus
+	}{
+		{parsed: 1.23456, stored: 1.23, want: RowUnchanged},
+		{parsed: 1.235, stored: 1.24, want: RowUnchanged},
+		{parsed: -1.235, stored: -1.24, want: RowUnchanged},
+		{parsed: 1.236, stored: 1.23, want: RowChanged},
+	}
+
+	for _, tt := range tests {
+		report := Diff([]models.QuarterData{row(tt.parsed)}, []models.QuarterData{row(tt.stored)}, models.ImportModeMerge)
+		if got := report.Rows[0].Status; got != tt.want {
+			t.Errorf("parsed %v over stored %v: status %s, want %s (%+v)", tt.parsed, tt.stored, got, tt.want, report.Rows[0].Changes)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"maps"
+
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+type rowKey struct {
+	company    string
+	year       int
+	quarter    string
+	periodType string
+}
+
+func keyOf(data models.QuarterData) rowKey {
+	return rowKey{company: data.Company, year: data.Year, quarter: data.Quarter, periodType: data.PeriodType}
+}
+
+// Merge folds the sparse per-metric rows produced by the parser into one row per
+// (company, year, quarter, period type), keeping the order in which keys were first seen.
+func Merge(items []models.QuarterData) []models.QuarterData {
+	positions := make(map[rowKey]int)
+	var merged []models.QuarterData
+
+	for _, item := range items {
+		key := keyOf(item)
+		pos, ok := positions[key]
+		if !ok {
+			positions[key] = len(merged)
+			item.Values = maps.Clone(item.Values)
+			merged = append(merged, item)
+			continue
+		}
+
+		target := &merged[pos]
+		if !item.ReportDate.IsZero() {
+			target.ReportDate = item.ReportDate
+		}
+		if item.Currency != "" {
+			target.Currency = item.Currency
+		}
+		for key, value := range item.Values {
+			if !value.IsMissing() {
+				target.SetValue(key, value)
+			}
+		}
+	}
+
+	return merged
+}
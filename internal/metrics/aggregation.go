@@ -0,0 +1,52 @@
+package metrics
+
+import "fmt"
+
+// Aggregation combines the quarterly values of a stored metric into one value for a longer period.
+type Aggregation string
+
+const (
+	// AggregationSum suits flows such as revenue, which accumulate over the period.
+	AggregationSum Aggregation = "sum"
+	// AggregationAvg suits rates such as ROE, which are averaged over the period.
+	AggregationAvg Aggregation = "avg"
+	// AggregationLast suits balances and multiples, which are taken at the end of the period.
+	AggregationLast Aggregation = "last"
+)
+
+// defaultAggregation sums money and averages ratios and percentages.
+func defaultAggregation(kind Kind) Aggregation {
+	if kind == KindMoney {
+		return AggregationSum
+	}
+	return AggregationAvg
+}
+
+func (a Aggregation) validate() error {
+	switch a {
+	case AggregationSum, AggregationAvg, AggregationLast:
+		return nil
+	default:
+		return fmt.Errorf("unknown aggregation %q", a)
+	}
+}
+
+// Apply aggregates values, which must be ordered from oldest to latest and not be empty.
+func (a Aggregation) Apply(values []float64) float64 {
+	switch a {
+	case AggregationLast:
+		return values[len(values)-1]
+	case AggregationAvg:
+		return sum(values) / float64(len(values))
+	default:
+		return sum(values)
+	}
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
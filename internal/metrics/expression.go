@@ -0,0 +1,243 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"unicode"
+)
+
+// Expression is an arithmetic formula over metric keys, e.g. "(capitalization + debt) / ebitda".
+// It supports numbers, metric keys, parentheses, unary minus and the operators + - * /.
+type Expression struct {
+	source string
+	root   node
+	inputs []string
+}
+
+type node interface {
+	eval(values map[string]float64) (float64, bool)
+}
+
+type number float64
+
+type reference string
+
+type negation struct {
+	operand node
+}
+
+type binary struct {
+	op          byte
+	left, right node
+}
+
+func ParseExpression(source string) (*Expression, error) {
+	// Metric keys are ASCII, which lets the tokenizer work on bytes.
+	for i, c := range source {
+		if c > unicode.MaxASCII {
+			return nil, fmt.Errorf("invalid expression %q: non-ASCII character %q at offset %d", source, c, i)
+		}
+	}
+
+	p := &expressionParser{source: source}
+	p.next()
+
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+	if p.token != "" {
+		return nil, fmt.Errorf("invalid expression %q: unexpected %q", source, p.token)
+	}
+
+	return &Expression{source: source, root: root, inputs: p.inputs}, nil
+}
+
+func MustParseExpression(source string) *Expression {
+	expression, err := ParseExpression(source)
+	if err != nil {
+		panic(err)
+	}
+	return expression
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Inputs returns the metric keys the expression reads, in order of first use.
+func (e *Expression) Inputs() []string {
+	return append([]string(nil), e.inputs...)
+}
+
+// Eval computes the expression. It reports false when an input is missing, a division by zero
+// occurs or the result is not a finite number.
+func (e *Expression) Eval(values map[string]float64) (float64, bool) {
+	value, ok := e.root.eval(values)
+	if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
+
+func (n number) eval(map[string]float64) (float64, bool) {
+	return float64(n), true
+}
+
+func (r reference) eval(values map[string]float64) (float64, bool) {
+	value, ok := values[string(r)]
+	return value, ok
+}
+
+func (n negation) eval(values map[string]float64) (float64, bool) {
+	value, ok := n.operand.eval(values)
+	return -value, ok
+}
+
+func (b binary) eval(values map[string]float64) (float64, bool) {
+	left, ok := b.left.eval(values)
+	if !ok {
+		return 0, false
+	}
+	right, ok := b.right.eval(values)
+	if !ok {
+		return 0, false
+	}
+
+	switch b.op {
+	case '+':
+		return left + right, true
+	case '-':
+		return left - right, true
+	case '*':
+		return left * right, true
+	default:
+		if right == 0 {
+			return 0, false
+		}
+		return left / right, true
+	}
+}
+
+type expressionParser struct {
+	source string
+	pos    int
+	token  string
+	inputs []string
+}
+
+func (p *expressionParser) next() {
+	for p.pos < len(p.source) && unicode.IsSpace(rune(p.source[p.pos])) {
+		p.pos++
+	}
+	if p.pos == len(p.source) {
+		p.token = ""
+		return
+	}
+
+	start := p.pos
+	c := rune(p.source[p.pos])
+	switch {
+	case unicode.IsLetter(c) || c == '_':
+		for p.pos < len(p.source) && isIdentifierChar(rune(p.source[p.pos])) {
+			p.pos++
+		}
+	case unicode.IsDigit(c) || c == '.':
+		for p.pos < len(p.source) && (unicode.IsDigit(rune(p.source[p.pos])) || p.source[p.pos] == '.') {
+			p.pos++
+		}
+	default:
+		p.pos++
+	}
+	p.token = p.source[start:p.pos]
+}
+
+func (p *expressionParser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.token == "+" || p.token == "-" {
+		op := p.token[0]
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseProduct() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.token == "*" || p.token == "/" {
+		op := p.token[0]
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseUnary() (node, error) {
+	if p.token == "-" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negation{operand: operand}, nil
+	}
+	return p.parseOperand()
+}
+
+func (p *expressionParser) parseOperand() (node, error) {
+	token := p.token
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "(":
+		p.next()
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.token != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.next()
+		return inner, nil
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", token)
+		}
+		p.next()
+		return number(value), nil
+	case unicode.IsLetter(rune(token[0])) || token[0] == '_':
+		p.next()
+		if !slices.Contains(p.inputs, token) {
+			p.inputs = append(p.inputs, token)
+		}
+		return reference(token), nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+}
+
+func isIdentifierChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
+}
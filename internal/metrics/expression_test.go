@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpressionEval(t *testing.T) {
+	values := map[string]float64{"revenue": 200, "net_profit": 50, "debt": 30, "ebitda": 20, "capitalization": 70, "zero": 0}
+
+	tests := []struct {
+		source string
+		want   float64
+	}{
+		{"net_profit / revenue * 100", 25},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"100 / 10 / 5", 2},
+		{"-debt + ebitda", -10},
+		{"--debt", 30},
+		{"2 * -3", -6},
+		{"-(1 + 2) * 2", -6},
+		{"(capitalization + debt) / ebitda", 5},
+		{"((revenue))", 200},
+		{" .5 * revenue ", 100},
+		{"ebitda\t-\ndebt", -10},
+	}
+
+	for _, tt := range tests {
+		expression, err := ParseExpression(tt.source)
+		if err != nil {
+			t.Errorf("ParseExpression(%q): %v", tt.source, err)
+			continue
+		}
+		got, ok := expression.Eval(values)
+		if !ok || got != tt.want {
+			t.Errorf("Eval(%q) = %v, %v; want %v", tt.source, got, ok, tt.want)
+		}
+	}
+}
+
+func TestExpressionUndefined(t *testing.T) {
+	values := map[string]float64{"revenue": 200, "zero": 0}
+
+	for _, source := range []string{
+		"net_profit / revenue",
+		"revenue / net_profit",
+		"-net_profit",
+		"revenue / zero",
+		"revenue / (zero * 2)",
+		"zero / zero",
+	} {
+		expression, err := ParseExpression(source)
+		if err != nil {
+			t.Errorf("ParseExpression(%q): %v", source, err)
+			continue
+		}
+		if got, ok := expression.Eval(values); ok {
+			t.Errorf("Eval(%q) = %v, want no value", source, got)
+		}
+	}
+}
+
+func TestExpressionInputs(t *testing.T) {
+	expression := MustParseExpression("(capitalization + debt) / ebitda - debt")
+	want := []string{"capitalization", "debt", "ebitda"}
+	if got := expression.Inputs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Inputs() = %v, want %v", got, want)
+	}
+	if got := MustParseExpression("1 + 2").Inputs(); len(got) != 0 {
+		t.Errorf("Inputs() of a constant = %v, want none", got)
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"", "unexpected end"},
+		{"revenue +", "unexpected end"},
+		{"(revenue", "missing closing parenthesis"},
+		{"revenue)", `unexpected ")"`},
+		{"revenue debt", `unexpected "debt"`},
+		{"revenue * / debt", `unexpected "/"`},
+		{"1.2.3", "invalid number"},
+		{"revenue % debt", `unexpected "%"`},
+		{"выручка / revenue", "non-ASCII character"},
+		{"revenue / ebitdа", "non-ASCII character"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseExpression(tt.source)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseExpression(%q) error = %v, want it to contain %q", tt.source, err, tt.want)
+		}
+	}
+}
+
+func TestRegisterRejectsUnknownInputs(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"revenue / expression_test_missing", "unknown input expression_test_missing"},
+		{"net_margin * 2", "input net_margin is derived itself"},
+	}
+
+	for _, tt := range tests {
+		err := Register(Definition{Key: "expression_test_metric", Kind: KindRatio, Expression: MustParseExpression(tt.source)})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Register(%q) error = %v, want it to contain %q", tt.source, err, tt.want)
+		}
+	}
+	if _, ok := Lookup("expression_test_metric"); ok {
+		t.Error("a rejected metric must not be registered")
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import "fmt"
+
+type Formatter struct {
+	Cell    func(value float64, unit string) string
+	Tooltip func(unit string) string
+}
+
+func FormatterForKind(kind Kind) Formatter {
+	switch kind {
+	case KindMoney:
+		return Formatter{Cell: formatPlain, Tooltip: abbreviatedTooltip}
+	case KindRatio:
+		return Formatter{Cell: formatPlain, Tooltip: func(string) string { return plainTooltip("") }}
+	default:
+		return Formatter{Cell: formatPlain, Tooltip: plainTooltip}
+	}
+}
+
+func formatPlain(value float64, unit string) string {
+	return fmt.Sprintf("%.2f%s", value, unit)
+}
+
+func abbreviatedTooltip(unit string) string {
+	return `
+            function(params) {
+                let result = params[0].name + '<br/>';
+                for(let i = 0; i < params.length; i++) {
+                    if (params[i].value !== null && params[i].value !== undefined) {
+                        let value = params[i].value;
+                        let formattedValue;
+                        if (value >= 1000000000000) {
+                            formattedValue = (value / 1000000000000).toFixed(2) + 'T';
+                        } else if (value >= 1000000000) {
+                            formattedValue = (value / 1000000000).toFixed(2) + 'B';
+                        } else if (value >= 1000000) {
+                            formattedValue = (value / 1000000).toFixed(2) + 'M';
+                        } else if (value >= 1000) {
+                            formattedValue = (value / 1000).toFixed(2) + 'K';
+                        } else {
+                            formattedValue = value.toFixed(2);
+                        }
+                        result += params[i].marker + ' ' + 
+                                params[i].seriesName + ': ' + 
+                                formattedValue + '` + unit + `' + '<br/>';
+                    } else {
+                        result += params[i].marker + ' ' + 
+                                params[i].seriesName + ': No data<br/>';
+                    }
+                }
+                return result;
+            }
+        `
+}
+
+func plainTooltip(unit string) string {
+	return `
+            function(params) {
+                let result = params[0].name + '<br/>';
+                for(let i = 0; i < params.length; i++) {
+                    if (params[i].value !== null && params[i].value !== undefined) {
+                        result += params[i].marker + ' ' + 
+                                params[i].seriesName + ': ' + 
+                                params[i].value.toFixed(2) + '` + unit + `' + '<br/>';
+                    } else {
+                        result += params[i].marker + ' ' + 
+                                params[i].seriesName + ': No data<br/>';
+                    }
+                }
+                return result;
+            }
+        `
+}
@@ -0,0 +1,268 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+type Kind string
+
+const (
+	KindMoney   Kind = "money"
+	KindRatio   Kind = "ratio"
+	KindPercent Kind = "percent"
+)
+
+type Definition struct {
+	Key         string
+	DisplayName string
+	Unit        string
+	Kind        Kind
+	Formatter   Formatter
+	// Aggregation rolls quarterly values up; derived metrics roll up their inputs instead.
+	Aggregation Aggregation
+	// Expression is set for derived metrics, which are computed from stored metrics instead of imported.
+	Expression *Expression
+}
+
+func (d Definition) Derived() bool {
+	return d.Expression != nil
+}
+
+var registry = []Definition{
+	{
+		Key:         "revenue",
+		DisplayName: "Revenue",
+		Kind:        KindMoney,
+		Aggregation: AggregationSum,
+	},
+	{
+		Key:         "net_profit",
+		DisplayName: "Net Profit",
+		Kind:        KindMoney,
+		Aggregation: AggregationSum,
+	},
+	{
+		Key:         "ebitda",
+		DisplayName: "EBITDA",
+		Kind:        KindMoney,
+		Aggregation: AggregationSum,
+	},
+	{
+		Key:         "pe",
+		DisplayName: "P/E Ratio",
+		Kind:        KindRatio,
+		Aggregation: AggregationLast,
+	},
+	{
+		Key:         "ps",
+		DisplayName: "P/S Ratio",
+		Kind:        KindRatio,
+		Aggregation: AggregationLast,
+	},
+	{
+		Key:         "roe",
+		DisplayName: "ROE (%)",
+		Unit:        "%",
+		Kind:        KindPercent,
+		Aggregation: AggregationAvg,
+	},
+	{
+		Key:         "roa",
+		DisplayName: "ROA (%)",
+		Unit:        "%",
+		Kind:        KindPercent,
+		Aggregation: AggregationAvg,
+	},
+	{
+		Key:         "capitalization",
+		DisplayName: "Market Cap",
+		Kind:        KindMoney,
+		Aggregation: AggregationLast,
+	},
+	{
+		Key:         "debt",
+		DisplayName: "Debt",
+		Kind:        KindMoney,
+		Aggregation: AggregationLast,
+	},
+	{
+		Key:         "capex",
+		DisplayName: "CAPEX",
+		Kind:        KindMoney,
+		Aggregation: AggregationSum,
+	},
+	{
+		Key:         "opex",
+		DisplayName: "OPEX",
+		Kind:        KindMoney,
+		Aggregation: AggregationSum,
+	},
+	{
+		Key:         "dividends",
+		DisplayName: "Dividends income (%)",
+		Unit:        "%",
+		Kind:        KindPercent,
+		Aggregation: AggregationSum,
+	},
+	{
+		Key:         "net_margin",
+		DisplayName: "Net Margin (%)",
+		Unit:        "%",
+		Kind:        KindPercent,
+		Expression:  MustParseExpression("net_profit / revenue * 100"),
+	},
+	{
+		Key:         "ebitda_margin",
+		DisplayName: "EBITDA Margin (%)",
+		Unit:        "%",
+		Kind:        KindPercent,
+		Expression:  MustParseExpression("ebitda / revenue * 100"),
+	},
+	{
+		Key:         "debt_to_ebitda",
+		DisplayName: "Debt/EBITDA",
+		Kind:        KindRatio,
+		Expression:  MustParseExpression("debt / ebitda"),
+	},
+	{
+		Key:         "capex_to_revenue",
+		DisplayName: "CAPEX/Revenue (%)",
+		Unit:        "%",
+		Kind:        KindPercent,
+		Expression:  MustParseExpression("capex / revenue * 100"),
+	},
+	{
+		Key:         "opex_to_revenue",
+		DisplayName: "OPEX/Revenue (%)",
+		Unit:        "%",
+		Kind:        KindPercent,
+		Expression:  MustParseExpression("opex / revenue * 100"),
+	},
+	{
+		Key:         "ebitda_minus_capex",
+		DisplayName: "EBITDA - CAPEX",
+		Kind:        KindMoney,
+		Expression:  MustParseExpression("ebitda - capex"),
+	},
+	{
+		Key:         "ev_to_ebitda",
+		DisplayName: "EV/EBITDA",
+		Kind:        KindRatio,
+		Expression:  MustParseExpression("(capitalization + debt) / ebitda"),
+	},
+}
+
+var (
+	mu    sync.RWMutex
+	index = make(map[string]int, len(registry))
+)
+
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+func init() {
+	for i := range registry {
+		if _, ok := index[registry[i].Key]; ok {
+			panic(fmt.Sprintf("metrics: duplicate metric %q", registry[i].Key))
+		}
+		registry[i].Formatter = FormatterForKind(registry[i].Kind)
+		index[registry[i].Key] = i
+	}
+	for _, def := range registry {
+		if err := checkInputs(def); err != nil {
+			panic(fmt.Sprintf("metrics: %v", err))
+		}
+	}
+}
+
+// Register adds a metric to the registry. Values are stored by key, so a registered metric can be
+// imported, charted and served without a schema change. An empty Kind means KindMoney, an empty
+// Aggregation the default of its kind. A metric with an Expression is derived and may only read
+// metrics that are stored.
+func Register(def Definition) error {
+	if !keyPattern.MatchString(def.Key) {
+		return fmt.Errorf("invalid metric key %q: use up to 50 lowercase letters, digits and underscores", def.Key)
+	}
+	if def.Kind == "" {
+		def.Kind = KindMoney
+	}
+	switch def.Kind {
+	case KindMoney, KindRatio, KindPercent:
+	default:
+		return fmt.Errorf("metric %s: unknown kind %q", def.Key, def.Kind)
+	}
+	if def.Aggregation == "" {
+		def.Aggregation = defaultAggregation(def.Kind)
+	}
+	if err := def.Aggregation.validate(); err != nil {
+		return fmt.Errorf("metric %s: %w", def.Key, err)
+	}
+	if def.DisplayName == "" {
+		def.DisplayName = def.Key
+	}
+	def.Formatter = FormatterForKind(def.Kind)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := index[def.Key]; ok {
+		return fmt.Errorf("metric %s is already registered", def.Key)
+	}
+	if err := checkInputs(def); err != nil {
+		return err
+	}
+	index[def.Key] = len(registry)
+	registry = append(registry, def)
+
+	return nil
+}
+
+// checkInputs makes sure a derived metric only reads stored metrics, so evaluation never recurses.
+// The caller must hold mu or run before the registry is shared.
+func checkInputs(def Definition) error {
+	if !def.Derived() {
+		return nil
+	}
+	for _, input := range def.Expression.Inputs() {
+		i, ok := index[input]
+		if !ok {
+			return fmt.Errorf("metric %s: unknown input %s", def.Key, input)
+		}
+		if registry[i].Derived() {
+			return fmt.Errorf("metric %s: input %s is derived itself", def.Key, input)
+		}
+	}
+	return nil
+}
+
+func All() []Definition {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	result := make([]Definition, len(registry))
+	copy(result, registry)
+	return result
+}
+
+func Lookup(key string) (Definition, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	i, ok := index[key]
+	if !ok {
+		return Definition{}, false
+	}
+	return registry[i], true
+}
+
+func Keys() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	keys := make([]string, len(registry))
+	for i, def := range registry {
+		keys[i] = def.Key
+	}
+	return keys
+}
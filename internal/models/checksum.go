@@ -0,0 +1,8 @@
+package models
+
+// FileChecksum identifies what an import of a file was based on: the file content and the
+// mapping and manifest it was parsed with. A file is unchanged only when both match.
+type FileChecksum struct {
+	SHA256        string
+	MappingSHA256 string
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Company struct {
+	ID        int64     `json:"id"`
+	Ticker    string    `json:"ticker"`
+	Name      string    `json:"name"`
+	Category  string    `json:"category"`
+	Currency  string    `json:"currency,omitempty"`
+	Country   string    `json:"country,omitempty"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+}
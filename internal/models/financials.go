@@ -1,35 +1,71 @@
 package models
 
+import "time"
+
+type NullFloat64 struct {
+	Float64 float64
+	Valid   bool
+	Cleared bool
+}
+
+func Float(value float64) NullFloat64 {
+	return NullFloat64{Float64: value, Valid: true}
+}
+
+func Cleared() NullFloat64 {
+	return NullFloat64{Cleared: true}
+}
+
+func (n NullFloat64) IsMissing() bool {
+	return !n.Valid && !n.Cleared
+}
+
+type ImportMode string
+
+const (
+	ImportModeMerge   ImportMode = "merge"
+	ImportModeReplace ImportMode = "replace"
+)
+
+const (
+	PeriodQuarter = "quarter"
+	PeriodHalf    = "half"
+	PeriodYear    = "year"
+	PeriodLTM     = "ltm"
+)
+
+// QuarterData is one period of a company. Values holds the metrics by registry key; a key that
+// is absent is missing, so any registered metric can be stored without changing this type.
 type QuarterData struct {
-	Year           int
-	Quarter        string
-	Company        string
-	Category       string
-	Capitalization float64
-	Revenue        float64
-	NetProfit      float64
-	EBITDA         float64
-	Debt           float64
-	PE             float64
-	PS             float64
-	ROE            float64
-	ROA            float64
-	CAPEX          float64
-	OPEX           float64
-	Dividends      float64
+	Year       int
+	Quarter    string
+	PeriodType string
+	Company    string
+	Category   string
+	ReportDate time.Time
+	Currency   string
+	Values     map[string]NullFloat64
+}
+
+func (q *QuarterData) Value(key string) NullFloat64 {
+	return q.Values[key]
+}
+
+func (q *QuarterData) SetValue(key string, value NullFloat64) {
+	if q.Values == nil {
+		q.Values = make(map[string]NullFloat64)
+	}
+	q.Values[key] = value
 }
 
 func (q *QuarterData) IsEmpty() bool {
-	return q.Capitalization == 0 &&
-		q.Revenue == 0 &&
-		q.NetProfit == 0 &&
-		q.EBITDA == 0 &&
-		q.Debt == 0 &&
-		q.PE == 0 &&
-		q.PS == 0 &&
-		q.ROE == 0 &&
-		q.ROA == 0 &&
-		q.CAPEX == 0 &&
-		q.OPEX == 0 &&
-		q.Dividends == 0
+	if !q.ReportDate.IsZero() || q.Currency != "" {
+		return false
+	}
+	for _, value := range q.Values {
+		if !value.IsMissing() {
+			return false
+		}
+	}
+	return true
 }
@@ -1,10 +1,20 @@
 package models
 
+import (
+	"time"
+)
+
 type CompanyMetric struct {
-	Year    int
-	Quarter string
-	Company string
-	Value   float64
+	Year       int
+	Quarter    string
+	Company    string
+	Value      float64
+	ReportDate *time.Time
+	Currency   string
+}
+
+func (m CompanyMetric) PeriodEnd() time.Time {
+	return PeriodEnd(m.Year, m.Quarter)
 }
 
 type QuarterPoint struct {
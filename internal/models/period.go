@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const LabelFullYear = "FY"
+
+type Period struct {
+	Year  int
+	Type  string
+	Label string
+}
+
+var periodPattern = regexp.MustCompile(`^(\d{4})[\s_/-]*(Q[1-4]|[1-4]Q|H[12]|[12]H|FY|Y)?$`)
+
+// ParsePeriod accepts headers like "2023Q1", "2023-Q1", "2023H1", "2023FY" and a bare "2023" for a full year.
+func ParsePeriod(s string) (Period, error) {
+	match := periodPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if match == nil {
+		return Period{}, fmt.Errorf("invalid period format: %s", s)
+	}
+
+	year, err := strconv.Atoi(match[1])
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid year: %w", err)
+	}
+
+	suffix := match[2]
+	switch {
+	case suffix == "" || suffix == "Y" || suffix == LabelFullYear:
+		return Period{Year: year, Type: PeriodYear, Label: LabelFullYear}, nil
+	case strings.Contains(suffix, "Q"):
+		return Period{Year: year, Type: PeriodQuarter, Label: "Q" + strings.Trim(suffix, "Q")}, nil
+	default:
+		return Period{Year: year, Type: PeriodHalf, Label: "H" + strings.Trim(suffix, "H")}, nil
+	}
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf("%d-%s", p.Year, p.Label)
+}
+
+func (p Period) End() time.Time {
+	return PeriodEnd(p.Year, p.Label)
+}
+
+func (p Period) Before(other Period) bool {
+	if p.Year != other.Year {
+		return p.Year < other.Year
+	}
+	return PeriodRank(p.Label) < PeriodRank(other.Label)
+}
+
+// PeriodRank orders labels within a year by period end, with shorter periods first.
+func PeriodRank(label string) int {
+	switch label {
+	case "Q1":
+		return 1
+	case "Q2":
+		return 2
+	case "H1":
+		return 3
+	case "Q3":
+		return 4
+	case "Q4":
+		return 5
+	case "H2":
+		return 6
+	case LabelFullYear:
+		return 7
+	}
+	return 0
+}
+
+func PeriodEnd(year int, label string) time.Time {
+	months := 12
+	if len(label) == 2 {
+		if n, err := strconv.Atoi(label[1:]); err == nil {
+			switch label[0] {
+			case 'Q':
+				months = n * 3
+			case 'H':
+				months = n * 6
+			}
+		}
+	}
+	return time.Date(year, time.Month(months+1), 0, 0, 0, 0, 0, time.UTC)
+}
+
+// PreviousPeriod returns the period of the same length that directly precedes label in year,
+// e.g. Q4 of the year before for Q1. FY and unknown labels step back a whole year.
+func PreviousPeriod(year int, label string) (int, string) {
+	switch label {
+	case "Q2", "Q3", "Q4", "H2":
+		return year, fmt.Sprintf("%c%d", label[0], label[1]-'0'-1)
+	case "Q1":
+		return year - 1, "Q4"
+	case "H1":
+		return year - 1, "H2"
+	}
+	return year - 1, label
+}
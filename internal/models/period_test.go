@@ -0,0 +1,102 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Period
+	}{
+		{"2023Q1", Period{Year: 2023, Type: PeriodQuarter, Label: "Q1"}},
+		{"2023-Q2", Period{Year: 2023, Type: PeriodQuarter, Label: "Q2"}},
+		{"2023 3Q", Period{Year: 2023, Type: PeriodQuarter, Label: "Q3"}},
+		{" 2023_q4 ", Period{Year: 2023, Type: PeriodQuarter, Label: "Q4"}},
+		{"2023H1", Period{Year: 2023, Type: PeriodHalf, Label: "H1"}},
+		{"2023/2H", Period{Year: 2023, Type: PeriodHalf, Label: "H2"}},
+		{"2023FY", Period{Year: 2023, Type: PeriodYear, Label: LabelFullYear}},
+		{"2023Y", Period{Year: 2023, Type: PeriodYear, Label: LabelFullYear}},
+		{"2023", Period{Year: 2023, Type: PeriodYear, Label: LabelFullYear}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePeriod(tt.input)
+		if err != nil {
+			t.Errorf("ParsePeriod(%q): %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePeriod(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParsePeriodInvalid(t *testing.T) {
+	for _, input := range []string{"", "Q1", "23Q1", "2023Q5", "2023Q0", "2023H3", "2023-M1", "2023Q1Q2", "LTM"} {
+		if got, err := ParsePeriod(input); err == nil {
+			t.Errorf("ParsePeriod(%q) = %+v, want an error", input, got)
+		}
+	}
+}
+
+func TestPeriodRank(t *testing.T) {
+	order := []string{"Q1", "Q2", "H1", "Q3", "Q4", "H2", LabelFullYear}
+	for i := 1; i < len(order); i++ {
+		if PeriodRank(order[i-1]) >= PeriodRank(order[i]) {
+			t.Errorf("%s must rank before %s", order[i-1], order[i])
+		}
+	}
+	for _, label := range []string{"", "Q5", "1", "LTM"} {
+		if rank := PeriodRank(label); rank != 0 {
+			t.Errorf("PeriodRank(%q) = %d, want 0", label, rank)
+		}
+	}
+}
+
+func TestPeriodEnd(t *testing.T) {
+	tests := []struct {
+		label string
+		want  time.Time
+	}{
+		{"Q1", time.Date(2023, time.March, 31, 0, 0, 0, 0, time.UTC)},
+		{"Q2", time.Date(2023, time.June, 30, 0, 0, 0, 0, time.UTC)},
+		{"Q3", time.Date(2023, time.September, 30, 0, 0, 0, 0, time.UTC)},
+		{"Q4", time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{"H1", time.Date(2023, time.June, 30, 0, 0, 0, 0, time.UTC)},
+		{"H2", time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{LabelFullYear, time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := PeriodEnd(2023, tt.label); !got.Equal(tt.want) {
+			t.Errorf("PeriodEnd(2023, %q) = %s, want %s", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestPreviousPeriod(t *testing.T) {
+	tests := []struct {
+		year      int
+		label     string
+		wantYear  int
+		wantLabel string
+	}{
+		{2023, "Q4", 2023, "Q3"},
+		{2023, "Q3", 2023, "Q2"},
+		{2023, "Q2", 2023, "Q1"},
+		{2023, "Q1", 2022, "Q4"},
+		{2023, "H2", 2023, "H1"},
+		{2023, "H1", 2022, "H2"},
+		{2023, LabelFullYear, 2022, LabelFullYear},
+		{2023, "Q5", 2022, "Q5"},
+	}
+
+	for _, tt := range tests {
+		year, label := PreviousPeriod(tt.year, tt.label)
+		if year != tt.wantYear || label != tt.wantLabel {
+			t.Errorf("PreviousPeriod(%d, %q) = %d %s, want %d %s", tt.year, tt.label, year, label, tt.wantYear, tt.wantLabel)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var reportDateLayouts = []string{"02.01.2006", "2006-01-02", "02/01/2006", "02.01.06"}
+
+var currencyAliases = map[string]string{
+	"РУБ":  "RUB",
+	"RUR":  "RUB",
+	"₽":    "RUB",
+	"$":    "USD",
+	"ДОЛ":  "USD",
+	"€":    "EUR",
+	"ЕВРО": "EUR",
+	"¥":    "CNY",
+	"ЮАНЬ": "CNY",
+}
+
+func ParseReportDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range reportDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid report date: %s", value)
+}
+
+func NormalizeCurrency(value string) (string, error) {
+	currency := strings.ToUpper(strings.TrimSpace(value))
+	currency = strings.TrimSuffix(currency, ".")
+	if alias, ok := currencyAliases[currency]; ok {
+		currency = alias
+	}
+
+	if len(currency) != 3 {
+		return "", fmt.Errorf("invalid currency: %s", value)
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("invalid currency: %s", value)
+		}
+	}
+
+	return currency, nil
+}
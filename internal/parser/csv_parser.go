@@ -1,59 +1,148 @@
 package parser
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
-	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
 	"github.com/VxVxN/financialanalyzer/internal/models"
 )
 
+var errEmptyValue = errors.New("empty value")
+
 type CSVParser struct {
-	rootPath string
-	logger   *slog.Logger
+	rootPath  string
+	mapping   *Mapping
+	workers   int
+	checksums map[string]models.FileChecksum
+	manifests *manifestCache
+	logger    *slog.Logger
 }
 
-func NewCSVParser(rootPath string, logger *slog.Logger) *CSVParser {
-	return &CSVParser{rootPath: rootPath, logger: logger}
+func NewCSVParser(rootPath string, mapping *Mapping, workers int, logger *slog.Logger) *CSVParser {
+	if mapping == nil {
+		mapping = DefaultMapping()
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return &CSVParser{rootPath: rootPath, mapping: mapping, workers: workers, manifests: newManifestCache(), logger: logger}
 }
 
-type MetricHandler func(*models.QuarterData, float64)
+type FileResult struct {
+	Path   string
+	SHA256 string
+	// MappingSHA256 covers the mapping and the resolved manifest the file was parsed with.
+	MappingSHA256 string
+	Size          int64
+	Company       CompanyInfo
+	Skipped       bool
+	Data          []models.QuarterData
+	Diagnostics   Diagnostics
+	Err           error
+}
 
-type MetricConfig struct {
-	Name        string
-	Handler     MetricHandler
-	IsSpecial   bool
-	SpecialType string
+type Result struct {
+	Files []FileResult
 }
 
-func (p *CSVParser) Parse() ([]models.QuarterData, error) {
-	var allResults []models.QuarterData
+func (r *Result) Data() []models.QuarterData {
+	var data []models.QuarterData
+	for _, file := range r.Files {
+		data = append(data, file.Data...)
+	}
+	return data
+}
 
-	err := filepath.Walk(p.rootPath, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+func (r *Result) Skipped() int {
+	count := 0
+	for _, file := range r.Files {
+		if file.Skipped {
+			count++
 		}
+	}
+	return count
+}
 
-		if info.IsDir() || !strings.HasSuffix(strings.ToLower(info.Name()), ".csv") {
-			return nil
+func (r *Result) Diagnostics() Diagnostics {
+	var diagnostics Diagnostics
+	for _, file := range r.Files {
+		diagnostics = append(diagnostics, file.Diagnostics...)
+	}
+	return diagnostics
+}
+
+// SkipUnchanged makes Parse skip files whose content, mapping and manifest match the checksums known
+// for their path, so editing the mapping or a manifest imports the affected files again.
+func (p *CSVParser) SkipUnchanged(checksums map[string]models.FileChecksum) {
+	p.checksums = checksums
+}
+
+func (p *CSVParser) Parse(ctx context.Context) (*Result, error) {
+	paths, err := p.collectFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileResult, len(paths))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				files[idx] = p.parsePath(paths[idx])
+			}
+		}()
+	}
+
+feed:
+	for idx := range paths {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- idx:
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		p.logger.Debug("Parsing file", "path", filePath)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-		fileParser := &CSVParser{rootPath: filePath}
-		results, err := fileParser.parseFile()
+	return &Result{Files: files}, nil
+}
+
+func (p *CSVParser) collectFiles() ([]string, error) {
+	var paths []string
+
+	err := filepath.WalkDir(p.rootPath, func(filePath string, entry fs.DirEntry, err error) error {
 		if err != nil {
-			p.logger.Error("Error parsing file", "path", filePath, "err", err)
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".csv") {
 			return nil
 		}
 
-		allResults = append(allResults, results...)
+		paths = append(paths, filePath)
 		return nil
 	})
 
@@ -61,22 +150,84 @@ func (p *CSVParser) Parse() ([]models.QuarterData, error) {
 		return nil, fmt.Errorf("failed to walk directory: %w", err)
 	}
 
-	return allResults, nil
+	return paths, nil
 }
 
-func (p *CSVParser) parseFile() ([]models.QuarterData, error) {
-	file, err := os.Open(p.rootPath)
+func (p *CSVParser) parsePath(filePath string) FileResult {
+	p.logger.Debug("Parsing file", "path", filePath)
+
+	fileResult := p.parseFile(filePath)
+	if fileResult.Err != nil {
+		p.logger.Error("Error parsing file", "path", filePath, "err", fileResult.Err)
+	}
+
+	return fileResult
+}
+
+func (p *CSVParser) parseFile(path string) FileResult {
+	result := FileResult{Path: path}
+
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return p.skipFile(result, fmt.Errorf("failed to read file: %w", err))
 	}
-	defer file.Close()
 
-	records, err := p.readCSV(file)
+	checksum := sha256.Sum256(content)
+	result.SHA256 = hex.EncodeToString(checksum[:])
+	result.Size = int64(len(content))
+
+	result.Company, err = p.manifests.resolveCompany(path)
 	if err != nil {
-		return nil, err
+		return p.skipFile(result, err)
+	}
+
+	result.MappingSHA256, err = p.mappingChecksum(result.Company)
+	if err != nil {
+		return p.skipFile(result, err)
+	}
+
+	current := models.FileChecksum{SHA256: result.SHA256, MappingSHA256: result.MappingSHA256}
+	if previous, ok := p.checksums[path]; ok && previous == current {
+		p.logger.Debug("Skipping unchanged file", "path", path)
+		result.Skipped = true
+		return result
+	}
+
+	records, err := p.readCSV(bytes.NewReader(content))
+	if err != nil {
+		return p.skipFile(result, err)
+	}
+
+	result.Data, result.Diagnostics = p.processRecords(records, result.Company)
+	for i := range result.Diagnostics {
+		result.Diagnostics[i].File = path
+	}
+	return result
+}
+
+// mappingChecksum hashes the effective parse settings of a file: the mapping and its resolved company.
+func (p *CSVParser) mappingChecksum(company CompanyInfo) (string, error) {
+	settings, err := json.Marshal(struct {
+		Mapping *Mapping    `json:"mapping"`
+		Company CompanyInfo `json:"company"`
+	}{p.mapping, company})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode mapping: %w", err)
 	}
 
-	return p.processRecords(records)
+	checksum := sha256.Sum256(settings)
+	return hex.EncodeToString(checksum[:]), nil
+}
+
+func (p *CSVParser) skipFile(result FileResult, err error) FileResult {
+	result.Err = err
+	result.Diagnostics = append(result.Diagnostics, Diagnostic{
+		Kind:     DiagnosticSkippedFile,
+		Severity: SeverityError,
+		File:     result.Path,
+		Message:  err.Error(),
+	})
+	return result
 }
 
 func (p *CSVParser) readCSV(file io.Reader) ([][]string, error) {
@@ -97,14 +248,21 @@ func (p *CSVParser) readCSV(file io.Reader) ([][]string, error) {
 	return records, nil
 }
 
-func (p *CSVParser) processRecords(records [][]string) ([]models.QuarterData, error) {
-	quarters := records[0]
-	companyName, category := p.extractCompanyNameAndCategory()
+type quarterColumn struct {
+	index      int
+	year       int
+	quarter    string
+	periodType string
+	// anchorType is the period type of the column an LTM column is anchored to.
+	anchorType string
+}
+
+func (p *CSVParser) processRecords(records [][]string, company CompanyInfo) ([]models.QuarterData, Diagnostics) {
+	companyName, category := company.Ticker, company.Category
+	quarters, diagnostics := p.parseQuarterColumns(records[0])
 
 	var results []models.QuarterData
 
-	metricHandlers := p.getMetricHandlers()
-
 	for rowIdx := 1; rowIdx < len(records); rowIdx++ {
 		record := records[rowIdx]
 		if len(record) == 0 {
@@ -112,160 +270,325 @@ func (p *CSVParser) processRecords(records [][]string) ([]models.QuarterData, er
 		}
 
 		metricName := strings.TrimSpace(record[0])
-		if p.shouldSkipMetric(metricName) {
+		if metricName == "" {
 			continue
 		}
 
-		metricConfig := p.detectMetricConfig(metricName, metricHandlers)
-		if metricConfig == nil {
+		rule := p.mapping.Match(metricName)
+		if rule == nil {
+			diagnostics = append(diagnostics, Diagnostic{
+				Kind:     DiagnosticUnknownMetric,
+				Severity: SeverityWarning,
+				Row:      rowIdx + 1,
+				Column:   1,
+				Raw:      record[0],
+				Message:  "no mapping rule matches the row label",
+			})
+			continue
+		}
+		if rule.Ignore {
 			continue
 		}
 
-		data, err := p.processMetricRow(metricConfig, quarters, record, companyName, category)
-		if err != nil {
+		if isAttributeField(rule.Field) {
+			data, rowDiagnostics := p.processAttributeRow(rule.Field, quarters, record, rowIdx, companyName, category)
+			results = append(results, data...)
+			diagnostics = append(diagnostics, rowDiagnostics...)
+			continue
+		}
+
+		metric, ok := metrics.Lookup(rule.Field)
+		if !ok {
 			continue
 		}
 
+		data, rowDiagnostics := p.processMetricRow(metric, quarters, record, rowIdx, companyName, category)
 		results = append(results, data...)
+		diagnostics = append(diagnostics, rowDiagnostics...)
+	}
+
+	results = append(results, ltmAttributeRows(results, quarters, company)...)
+	if company.Currency != "" {
+		results = append(results, defaultCurrencyRows(results, quarters, company)...)
 	}
 
-	return results, nil
+	return results, diagnostics
 }
 
-func (p *CSVParser) extractCompanyNameAndCategory() (string, string) {
-	filename := path.Base(p.rootPath)
-	filename = strings.TrimSuffix(filename, filepath.Ext(filename))
-	splitedFilename := strings.Split(filename, "_")
+// ltmAttributeRows gives the LTM column the reporting currency and report date of the column it is
+// anchored to, as files usually leave both blank in the LTM column.
+func ltmAttributeRows(results []models.QuarterData, quarters []quarterColumn, company CompanyInfo) []models.QuarterData {
+	var rows []models.QuarterData
+	for _, column := range quarters {
+		if column.periodType != models.PeriodLTM {
+			continue
+		}
+
+		anchor := models.Period{Year: column.year, Type: column.anchorType, Label: column.quarter}
+		var anchorData, ltmData models.QuarterData
+		for _, item := range results {
+			switch (models.Period{Year: item.Year, Type: item.PeriodType, Label: item.Quarter}) {
+			case anchor:
+				mergeAttributes(&anchorData, item)
+			case column.period():
+				mergeAttributes(&ltmData, item)
+			}
+		}
 
-	if len(splitedFilename) < 2 {
-		return filename, "unknown"
+		row := models.QuarterData{
+			Year:       column.year,
+			Quarter:    column.quarter,
+			PeriodType: column.periodType,
+			Company:    company.Ticker,
+			Category:   company.Category,
+		}
+		if ltmData.Currency == "" {
+			row.Currency = anchorData.Currency
+		}
+		if ltmData.ReportDate.IsZero() {
+			row.ReportDate = anchorData.ReportDate
+		}
+		if row.Currency != "" || !row.ReportDate.IsZero() {
+			rows = append(rows, row)
+		}
 	}
 
-	return splitedFilename[0], splitedFilename[1]
+	return rows
 }
 
-func (p *CSVParser) shouldSkipMetric(metricName string) bool {
-	return metricName == "Дата отчета" || metricName == "Валюта отчета"
+func mergeAttributes(target *models.QuarterData, item models.QuarterData) {
+	if item.Currency != "" {
+		target.Currency = item.Currency
+	}
+	if !item.ReportDate.IsZero() {
+		target.ReportDate = item.ReportDate
+	}
 }
 
-func (p *CSVParser) getMetricHandlers() map[string]MetricHandler {
-	return map[string]MetricHandler{
-		"Капитализация": func(d *models.QuarterData, v float64) { d.Capitalization = v },
-		"Выручка":       func(d *models.QuarterData, v float64) { d.Revenue = v },
-		"EBITDA":        func(d *models.QuarterData, v float64) { d.EBITDA = v },
-		"ROE":           func(d *models.QuarterData, v float64) { d.ROE = v },
-		"ROA":           func(d *models.QuarterData, v float64) { d.ROA = v },
-		"Опер. расходы": func(d *models.QuarterData, v float64) { d.OPEX = v },
-		"Див доход":     func(d *models.QuarterData, v float64) { d.Dividends = v },
+// defaultCurrencyRows fills in the manifest currency for columns without a reporting currency row.
+func defaultCurrencyRows(results []models.QuarterData, quarters []quarterColumn, company CompanyInfo) []models.QuarterData {
+	reported := make(map[models.Period]bool)
+	for _, item := range results {
+		if item.Currency != "" {
+			reported[models.Period{Year: item.Year, Type: item.PeriodType, Label: item.Quarter}] = true
+		}
 	}
+
+	var rows []models.QuarterData
+	for _, column := range quarters {
+		if reported[column.period()] {
+			continue
+		}
+		rows = append(rows, models.QuarterData{
+			Year:       column.year,
+			Quarter:    column.quarter,
+			PeriodType: column.periodType,
+			Company:    company.Ticker,
+			Category:   company.Category,
+			Currency:   company.Currency,
+		})
+	}
+
+	return rows
 }
 
-func (p *CSVParser) detectMetricConfig(metricName string, handlers map[string]MetricHandler) *MetricConfig {
-	switch {
-	case strings.Contains(metricName, "P/E"):
-		return &MetricConfig{IsSpecial: true, SpecialType: "PE"}
-	case strings.Contains(metricName, "P/S"):
-		return &MetricConfig{IsSpecial: true, SpecialType: "PS"}
-	case strings.HasPrefix(metricName, "Долг") && !strings.Contains(metricName, "EBITDA"):
-		return &MetricConfig{IsSpecial: true, SpecialType: "DEBT"}
-	case strings.HasPrefix(metricName, "Чистая прибыль") && !strings.Contains(metricName, "н/с"):
-		return &MetricConfig{IsSpecial: true, SpecialType: "NET_PROFIT"}
-	case strings.HasPrefix(metricName, "CAPEX") && !strings.Contains(metricName, "/"):
-		return &MetricConfig{IsSpecial: true, SpecialType: "CAPEX"}
-	}
-
-	for key, handler := range handlers {
-		if strings.HasPrefix(metricName, key) {
-			return &MetricConfig{
-				Name:    key,
-				Handler: handler,
-			}
+func (p *CSVParser) parseQuarterColumns(header []string) ([]quarterColumn, Diagnostics) {
+	var columns []quarterColumn
+	var diagnostics Diagnostics
+	ltmIdx := -1
+
+	for colIdx := 1; colIdx < len(header); colIdx++ {
+		quarterStr := strings.TrimSpace(header[colIdx])
+		if quarterStr == "" {
+			continue
 		}
+		if quarterStr == "LTM" {
+			ltmIdx = colIdx
+			continue
+		}
+
+		period, err := models.ParsePeriod(quarterStr)
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{
+				Kind:     DiagnosticBadPeriod,
+				Severity: SeverityError,
+				Row:      1,
+				Column:   colIdx + 1,
+				Raw:      header[colIdx],
+				Message:  err.Error(),
+			})
+			continue
+		}
+
+		columns = append(columns, quarterColumn{index: colIdx, year: period.Year, quarter: period.Label, periodType: period.Type})
 	}
 
-	return nil
+	if ltmIdx >= 0 {
+		ltm, ok := latestQuarter(columns)
+		if !ok {
+			diagnostics = append(diagnostics, Diagnostic{
+				Kind:     DiagnosticBadPeriod,
+				Severity: SeverityWarning,
+				Row:      1,
+				Column:   ltmIdx + 1,
+				Raw:      header[ltmIdx],
+				Message:  "LTM column has no period to anchor to",
+			})
+		} else {
+			ltm.index = ltmIdx
+			ltm.anchorType = ltm.periodType
+			ltm.periodType = models.PeriodLTM
+			columns = append(columns, ltm)
+		}
+	}
+
+	return columns, diagnostics
 }
 
-func (p *CSVParser) processMetricRow(config *MetricConfig, quarters []string, record []string, companyName string,
-	category string) ([]models.QuarterData, error) {
+// latestQuarter returns the most recent quarter column, falling back to half-year and annual
+// columns for files without quarters; LTM values are stored as of that period.
+func latestQuarter(columns []quarterColumn) (quarterColumn, bool) {
+	var latest quarterColumn
+	found := false
+	for _, column := range columns {
+		if !found || column.anchorsAfter(latest) {
+			latest = column
+			found = true
+		}
+	}
+
+	return latest, found
+}
+
+func (c quarterColumn) anchorsAfter(other quarterColumn) bool {
+	isQuarter := c.periodType == models.PeriodQuarter
+	if isQuarter != (other.periodType == models.PeriodQuarter) {
+		return isQuarter
+	}
+	return other.period().Before(c.period())
+}
+
+func (c quarterColumn) period() models.Period {
+	return models.Period{Year: c.year, Type: c.periodType, Label: c.quarter}
+}
+
+func (p *CSVParser) processMetricRow(metric metrics.Definition, quarters []quarterColumn, record []string, rowIdx int,
+	companyName string, category string) ([]models.QuarterData, Diagnostics) {
 
 	var results []models.QuarterData
+	var diagnostics Diagnostics
 
-	for colIdx := 1; colIdx < len(record) && colIdx < len(quarters); colIdx++ {
-		quarterStr := strings.TrimSpace(quarters[colIdx])
-		if quarterStr == "" || quarterStr == "LTM" {
+	for _, column := range quarters {
+		if column.index >= len(record) {
 			continue
 		}
 
-		year, quarter, err := ParseQuarter(quarterStr)
-		if err != nil {
+		value, err := p.parseValue(record[column.index])
+		if errors.Is(err, errEmptyValue) {
 			continue
 		}
-
-		value, err := p.parseValue(record[colIdx])
 		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{
+				Kind:     DiagnosticBadValue,
+				Severity: SeverityError,
+				Row:      rowIdx + 1,
+				Column:   column.index + 1,
+				Raw:      record[column.index],
+				Message:  fmt.Sprintf("invalid %s value", metric.Key),
+			})
 			continue
 		}
 
 		data := models.QuarterData{
-			Year:     year,
-			Quarter:  quarter,
-			Company:  companyName,
-			Category: category,
+			Year:       column.year,
+			Quarter:    column.quarter,
+			PeriodType: column.periodType,
+			Company:    companyName,
+			Category:   category,
 		}
 
-		p.applyMetricValue(config, &data, value)
+		data.SetValue(metric.Key, value)
 
 		if !data.IsEmpty() {
 			results = append(results, data)
 		}
 	}
 
-	return results, nil
+	return results, diagnostics
 }
 
-func (p *CSVParser) parseValue(valueStr string) (float64, error) {
-	valueStr = strings.TrimSpace(valueStr)
-	valueStr = strings.ReplaceAll(valueStr, ",", ".")
-	valueStr = strings.ReplaceAll(valueStr, " ", "")
-	valueStr = strings.ReplaceAll(valueStr, "\"", "")
-	valueStr = strings.ReplaceAll(valueStr, "%", "")
+func (p *CSVParser) processAttributeRow(field string, quarters []quarterColumn, record []string, rowIdx int,
+	companyName string, category string) ([]models.QuarterData, Diagnostics) {
+
+	var results []models.QuarterData
+	var diagnostics Diagnostics
+
+	for _, column := range quarters {
+		if column.index >= len(record) {
+			continue
+		}
+
+		raw := strings.TrimSpace(strings.ReplaceAll(record[column.index], "\"", ""))
+		if p.mapping.IsMissing(raw) {
+			continue
+		}
 
-	if valueStr == "" || valueStr == "-" || valueStr == "0.00" {
-		return 0, fmt.Errorf("empty or invalid value")
+		data := models.QuarterData{
+			Year:       column.year,
+			Quarter:    column.quarter,
+			PeriodType: column.periodType,
+			Company:    companyName,
+			Category:   category,
+		}
+
+		var err error
+		switch field {
+		case FieldReportDate:
+			data.ReportDate, err = ParseReportDate(raw)
+		case FieldCurrency:
+			data.Currency, err = NormalizeCurrency(raw)
+		}
+
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{
+				Kind:     DiagnosticBadValue,
+				Severity: SeverityError,
+				Row:      rowIdx + 1,
+				Column:   column.index + 1,
+				Raw:      record[column.index],
+				Message:  err.Error(),
+			})
+			continue
+		}
+
+		results = append(results, data)
 	}
 
-	return strconv.ParseFloat(valueStr, 64)
+	return results, diagnostics
 }
 
-func (p *CSVParser) applyMetricValue(config *MetricConfig, data *models.QuarterData, value float64) {
-	if config.IsSpecial {
-		switch config.SpecialType {
-		case "PE":
-			data.PE = value
-		case "PS":
-			data.PS = value
-		case "DEBT":
-			data.Debt = value
-		case "NET_PROFIT":
-			data.NetProfit = value
-		case "CAPEX":
-			data.CAPEX = value
-		}
-	} else if config.Handler != nil {
-		config.Handler(data, value)
+func (p *CSVParser) parseValue(valueStr string) (models.NullFloat64, error) {
+	valueStr = strings.TrimSpace(strings.ReplaceAll(valueStr, "\"", ""))
+
+	if p.mapping.IsMissing(valueStr) {
+		return models.NullFloat64{}, errEmptyValue
 	}
-}
+	if p.mapping.IsCleared(valueStr) {
+		return models.Cleared(), nil
+	}
+
+	valueStr = strings.ReplaceAll(valueStr, ",", ".")
+	valueStr = strings.ReplaceAll(valueStr, " ", "")
+	valueStr = strings.ReplaceAll(valueStr, "%", "")
 
-func ParseQuarter(q string) (int, string, error) {
-	if len(q) < 6 {
-		return 0, "", fmt.Errorf("invalid quarter format: %s", q)
+	if valueStr == "" {
+		return models.NullFloat64{}, errEmptyValue
 	}
 
-	year, err := strconv.Atoi(q[:4])
+	value, err := strconv.ParseFloat(valueStr, 64)
 	if err != nil {
-		return 0, "", fmt.Errorf("invalid year: %w", err)
+		return models.NullFloat64{}, err
 	}
 
-	return year, q[5:], nil
+	return models.Float(value), nil
 }
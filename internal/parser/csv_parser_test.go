@@ -0,0 +1,302 @@
+package parser
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VxVxN/financialanalyzer/internal/importer"
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+// writeFiles creates a directory of CSV files keyed by their path relative to it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func parseDir(t *testing.T, root string, workers int) *Result {
+	t.Helper()
+	result, err := NewCSVParser(root, nil, workers, slog.New(slog.NewTextHandler(io.Discard, nil))).Parse(context.Background())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return result
+}
+
+// rowsByPeriod merges the parsed rows the way the importer does and keys them by period.
+func rowsByPeriod(result *Result) map[string]models.QuarterData {
+	rows := make(map[string]models.QuarterData)
+	for _, row := range importer.Merge(result.Data()) {
+		rows[row.Company+" "+models.Period{Year: row.Year, Type: row.PeriodType, Label: row.Quarter}.String()+" "+row.PeriodType] = row
+	}
+	return rows
+}
+
+func TestLTMInheritsAnchorAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		key      string
+		currency string
+		date     time.Time
+	}{
+		{
+			name: "blank LTM cells take the latest quarter",
+			csv: "Показатель;2023Q3;2023Q4;LTM\n" +
+				"Дата отчета;01.11.2023;01.03.2024;\n" +
+				"Валюта отчета;USD;CNY;\n" +
+				"Выручка;1;2;10\n",
+			key:      "AAA 2023-Q4 ltm",
+			currency: "CNY",
+			date:     time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "reported LTM cells win",
+			csv: "Показатель;2023Q4;LTM\n" +
+				"Дата отчета;01.03.2024;15.03.2024\n" +
+				"Валюта отчета;CNY;USD\n" +
+				"Выручка;2;10\n",
+			key:      "AAA 2023-Q4 ltm",
+			currency: "USD",
+			date:     time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "a half-year anchor without quarters",
+			csv: "Показатель;2023H1;2023H2;LTM\n" +
+				"Дата отчета;01.08.2023;01.03.2024;-\n" +
+				"Валюта отчета;USD;USD;\n" +
+				"Выручка;1;2;3\n",
+			key:      "AAA 2023-H2 ltm",
+			currency: "USD",
+			date:     time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "an anchor without attributes",
+			csv: "Показатель;2023Q4;LTM\n" +
+				"Выручка;2;10\n",
+			key: "AAA 2023-Q4 ltm",
+		},
+	}
+
+	for _, tt := range tests {
+		rows := rowsByPeriod(parseDir(t, writeFiles(t, map[string]string{"AAA_tech.csv": tt.csv}), 1))
+		row, ok := rows[tt.key]
+		if !ok {
+			t.Errorf("%s: no %s row in %v", tt.name, tt.key, rows)
+			continue
+		}
+		if row.Currency != tt.currency || !row.ReportDate.Equal(tt.date) {
+			t.Errorf("%s: LTM currency %q and report date %v, want %q and %v",
+				tt.name, row.Currency, row.ReportDate, tt.currency, tt.date)
+		}
+		if row.Value("revenue") == (models.NullFloat64{}) {
+			t.Errorf("%s: LTM row lost its values: %+v", tt.name, row)
+		}
+	}
+}
+
+func TestShortRowKeepsLTM(t *testing.T) {
+	// The LTM column comes first in the header but is ordered after the quarters it is anchored to,
+	// so a row that ends before the last quarter must still reach it.
+	csv := "Показатель;LTM;2023Q3;2023Q4\n" +
+		"Валюта отчета;USD;USD\n" +
+		"Выручка;10;1\n"
+
+	rows := rowsByPeriod(parseDir(t, writeFiles(t, map[string]string{"AAA_tech.csv": csv}), 1))
+
+	if got := rows["AAA 2023-Q4 ltm"]; got.Value("revenue") != models.Float(10) || got.Currency != "USD" {
+		t.Errorf("LTM row = %+v, want revenue 10 in USD", got)
+	}
+	if got := rows["AAA 2023-Q3 quarter"]; got.Value("revenue") != models.Float(1) {
+		t.Errorf("2023-Q3 row = %+v, want revenue 1", got)
+	}
+	if _, ok := rows["AAA 2023-Q4 quarter"]; ok {
+		t.Errorf("2023-Q4 has no cells and must not produce a row: %+v", rows)
+	}
+}
+
+const fixtureCSV = "Показатель;2022Q4;2023Q1;2023Q2;LTM\n" +
+	"Дата отчета;01.03.2023;28.04.2023;31.07.2023;\n" +
+	"Валюта отчета;RUB;RUB;RUB;\n" +
+	"Выручка, млрд руб;\"1 000,5\";1 100;-;4 400\n" +
+	"Чистая прибыль, млрд руб;100;0;n/a;400\n" +
+	"Чистая прибыль н/с, млрд руб;1;2;3;4\n" +
+	"Долг/EBITDA;1,5;1,6;1,7;1,8\n" +
+	"ROE, %;12,5%;13%;;14%\n"
+
+func TestParseFixture(t *testing.T) {
+	rows := rowsByPeriod(parseDir(t, writeFiles(t, map[string]string{"SBER_banks.csv": fixtureCSV}), 1))
+
+	tests := []struct {
+		key    string
+		values map[string]models.NullFloat64
+	}{
+		{"SBER 2022-Q4 quarter", map[string]models.NullFloat64{
+			"revenue": models.Float(1000.5), "net_profit": models.Float(100), "roe": models.Float(12.5),
+		}},
+		{"SBER 2023-Q1 quarter", map[string]models.NullFloat64{
+			"revenue": models.Float(1100), "net_profit": models.Float(0), "roe": models.Float(13),
+		}},
+		{"SBER 2023-Q2 quarter", map[string]models.NullFloat64{
+			"net_profit": models.Cleared(),
+		}},
+		{"SBER 2023-Q2 ltm", map[string]models.NullFloat64{
+			"revenue": models.Float(4400), "net_profit": models.Float(400), "roe": models.Float(14),
+		}},
+	}
+
+	if len(rows) != len(tests) {
+		t.Errorf("expected %d rows, got %d: %v", len(tests), len(rows), rows)
+	}
+	for _, tt := range tests {
+		row, ok := rows[tt.key]
+		if !ok {
+			t.Errorf("missing row %s", tt.key)
+			continue
+		}
+		if row.Category != "banks" || row.Currency != "RUB" || row.ReportDate.IsZero() {
+			t.Errorf("%s: unexpected attributes %+v", tt.key, row)
+		}
+		if !reflect.DeepEqual(row.Values, tt.values) {
+			t.Errorf("%s: values %v, want %v", tt.key, row.Values, tt.values)
+		}
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	csv := "Показатель;2023Q1;2023Q5;2023Q2\n" +
+		"Дата отчета;31.04.2023;;01.08.2023\n" +
+		"Валюта отчета;RUB;;XX1\n" +
+		"Выручка;1;2;abc\n" +
+		"Активы;1;2;3\n"
+	root := writeFiles(t, map[string]string{"AAA_tech.csv": csv, "BBB_tech.csv": "Показатель\n"})
+	file := filepath.Join(root, "AAA_tech.csv")
+
+	want := Diagnostics{
+		{Kind: DiagnosticBadPeriod, Severity: SeverityError, File: file, Row: 1, Column: 3, Raw: "2023Q5"},
+		{Kind: DiagnosticBadValue, Severity: SeverityError, File: file, Row: 2, Column: 2, Raw: "31.04.2023"},
+		{Kind: DiagnosticBadValue, Severity: SeverityError, File: file, Row: 3, Column: 4, Raw: "XX1"},
+		{Kind: DiagnosticBadValue, Severity: SeverityError, File: file, Row: 4, Column: 4, Raw: "abc"},
+		{Kind: DiagnosticUnknownMetric, Severity: SeverityWarning, File: file, Row: 5, Column: 1, Raw: "Активы"},
+		{Kind: DiagnosticSkippedFile, Severity: SeverityError, File: filepath.Join(root, "BBB_tech.csv")},
+	}
+
+	got := parseDir(t, root, 1).Diagnostics()
+	for i := range got {
+		if got[i].Message == "" {
+			t.Errorf("diagnostic %+v has no message", got[i])
+		}
+		got[i].Message = ""
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics:\n%+v\nwant:\n%+v", got, want)
+	}
+}
+
+func TestParseWorkersKeepFileOrder(t *testing.T) {
+	files := make(map[string]string)
+	for _, name := range []string{"AAA", "BBB", "CCC", "DDD", "EEE", "FFF", "GGG", "HHH", "III", "JJJ"} {
+		files[filepath.Join(strings.ToLower(name[:1]), name+"_tech.csv")] = fixtureCSV
+	}
+	files["bad/KKK_tech.csv"] = "Показатель;2023Q9\nВыручка;1\n"
+	root := writeFiles(t, files)
+
+	sequential := parseDir(t, root, 1)
+	for _, workers := range []int{2, 8, 32} {
+		parallel := parseDir(t, root, workers)
+		if !reflect.DeepEqual(parallel.Files, sequential.Files) {
+			t.Errorf("%d workers changed the result", workers)
+		}
+		if !reflect.DeepEqual(parallel.Data(), sequential.Data()) {
+			t.Errorf("%d workers changed the data order", workers)
+		}
+	}
+}
+
+func TestParseQuarterColumns(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		want        []quarterColumn
+		diagnostics []DiagnosticKind
+	}{
+		{
+			name:   "quarter spellings",
+			header: "Показатель;2023Q1;2023-Q2;2023 3Q;2023_q4",
+			want: []quarterColumn{
+				{index: 1, year: 2023, quarter: "Q1", periodType: models.PeriodQuarter},
+				{index: 2, year: 2023, quarter: "Q2", periodType: models.PeriodQuarter},
+				{index: 3, year: 2023, quarter: "Q3", periodType: models.PeriodQuarter},
+				{index: 4, year: 2023, quarter: "Q4", periodType: models.PeriodQuarter},
+			},
+		},
+		{
+			name:   "half-year and annual columns",
+			header: "Показатель;2022;2023FY;2023H1;2023 2H;2023Y",
+			want: []quarterColumn{
+				{index: 1, year: 2022, quarter: "FY", periodType: models.PeriodYear},
+				{index: 2, year: 2023, quarter: "FY", periodType: models.PeriodYear},
+				{index: 3, year: 2023, quarter: "H1", periodType: models.PeriodHalf},
+				{index: 4, year: 2023, quarter: "H2", periodType: models.PeriodHalf},
+				{index: 5, year: 2023, quarter: "FY", periodType: models.PeriodYear},
+			},
+		},
+		{
+			name:   "LTM anchored to the latest quarter before a later year",
+			header: "Показатель;LTM;2023Q2;2023FY;2023Q3;;",
+			want: []quarterColumn{
+				{index: 2, year: 2023, quarter: "Q2", periodType: models.PeriodQuarter},
+				{index: 3, year: 2023, quarter: "FY", periodType: models.PeriodYear},
+				{index: 4, year: 2023, quarter: "Q3", periodType: models.PeriodQuarter},
+				{index: 1, year: 2023, quarter: "Q3", periodType: models.PeriodLTM, anchorType: models.PeriodQuarter},
+			},
+		},
+		{
+			name:   "LTM anchored to the latest half-year without quarters",
+			header: "Показатель;2022FY;2023H1;LTM",
+			want: []quarterColumn{
+				{index: 1, year: 2022, quarter: "FY", periodType: models.PeriodYear},
+				{index: 2, year: 2023, quarter: "H1", periodType: models.PeriodHalf},
+				{index: 3, year: 2023, quarter: "H1", periodType: models.PeriodLTM, anchorType: models.PeriodHalf},
+			},
+		},
+		{
+			name:        "LTM without a period",
+			header:      "Показатель;LTM;2023Q5",
+			want:        nil,
+			diagnostics: []DiagnosticKind{DiagnosticBadPeriod, DiagnosticBadPeriod},
+		},
+	}
+
+	p := NewCSVParser("", nil, 1, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, tt := range tests {
+		got, diagnostics := p.parseQuarterColumns(strings.Split(tt.header, ";"))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: columns %+v, want %+v", tt.name, got, tt.want)
+		}
+		var kinds []DiagnosticKind
+		for _, diagnostic := range diagnostics {
+			kinds = append(kinds, diagnostic.Kind)
+		}
+		if !reflect.DeepEqual(kinds, tt.diagnostics) {
+			t.Errorf("%s: diagnostics %v, want %v", tt.name, kinds, tt.diagnostics)
+		}
+	}
+}
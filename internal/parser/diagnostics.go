@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+type DiagnosticKind string
+
+const (
+	DiagnosticUnknownMetric DiagnosticKind = "unknown_metric"
+	DiagnosticBadPeriod     DiagnosticKind = "bad_period"
+	DiagnosticBadValue      DiagnosticKind = "bad_value"
+	DiagnosticSkippedFile   DiagnosticKind = "skipped_file"
+)
+
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+type Diagnostic struct {
+	Kind     DiagnosticKind `json:"kind"`
+	Severity Severity       `json:"severity"`
+	File     string         `json:"file"`
+	Row      int            `json:"row,omitempty"`
+	Column   int            `json:"column,omitempty"`
+	Raw      string         `json:"raw,omitempty"`
+	Message  string         `json:"message"`
+}
+
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Errors() int {
+	count := 0
+	for _, diagnostic := range d {
+		if diagnostic.Severity == SeverityError {
+			count++
+		}
+	}
+	return count
+}
+
+func (d Diagnostics) Warnings() int {
+	return len(d) - d.Errors()
+}
+
+func (d Diagnostics) Print(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		return d.PrintJSON(w)
+	case "table", "":
+		return d.PrintTable(w)
+	default:
+		return fmt.Errorf("unknown diagnostics format %q", format)
+	}
+}
+
+func (d Diagnostics) PrintTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "SEVERITY\tKIND\tFILE\tROW\tCOLUMN\tRAW\tMESSAGE")
+	for _, diagnostic := range d {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%q\t%s\n",
+			diagnostic.Severity, diagnostic.Kind, diagnostic.File,
+			formatPosition(diagnostic.Row), formatPosition(diagnostic.Column),
+			diagnostic.Raw, diagnostic.Message)
+	}
+	fmt.Fprintf(tw, "\nerrors: %d\twarnings: %d\n", d.Errors(), d.Warnings())
+
+	return tw.Flush()
+}
+
+func (d Diagnostics) PrintJSON(w io.Writer) error {
+	if d == nil {
+		d = Diagnostics{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Errors      int         `json:"errors"`
+		Warnings    int         `json:"warnings"`
+		Diagnostics Diagnostics `json:"diagnostics"`
+	}{
+		Errors:      d.Errors(),
+		Warnings:    d.Warnings(),
+		Diagnostics: d,
+	})
+}
+
+func formatPosition(position int) string {
+	if position == 0 {
+		return "-"
+	}
+	return fmt.Sprint(position)
+}
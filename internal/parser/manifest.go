@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/VxVxN/financialanalyzer/internal/models"
+)
+
+const DirectoryManifestName = "manifest.json"
+
+type CompanyInfo struct {
+	Ticker   string   `json:"ticker,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Country  string   `json:"country,omitempty"`
+	Currency string   `json:"currency,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// DirectoryManifest describes every CSV file in a directory: Defaults apply to all of them,
+// Files overrides them per file name.
+type DirectoryManifest struct {
+	Defaults CompanyInfo            `json:"defaults"`
+	Files    map[string]CompanyInfo `json:"files"`
+}
+
+type manifestCache struct {
+	mu   sync.Mutex
+	dirs map[string]*directoryManifestEntry
+}
+
+type directoryManifestEntry struct {
+	manifest *DirectoryManifest
+	err      error
+}
+
+func newManifestCache() *manifestCache {
+	return &manifestCache{dirs: make(map[string]*directoryManifestEntry)}
+}
+
+func (c *manifestCache) directory(dir string) (*DirectoryManifest, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.dirs[dir]; ok {
+		return entry.manifest, entry.err
+	}
+
+	entry := &directoryManifestEntry{}
+	content, err := os.ReadFile(filepath.Join(dir, DirectoryManifestName))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		entry.err = fmt.Errorf("failed to read directory manifest: %w", err)
+	default:
+		var manifest DirectoryManifest
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			entry.err = fmt.Errorf("invalid directory manifest %s: %w", filepath.Join(dir, DirectoryManifestName), err)
+		} else {
+			entry.manifest = &manifest
+		}
+	}
+
+	c.dirs[dir] = entry
+	return entry.manifest, entry.err
+}
+
+// resolveCompany layers, from lowest to highest priority, the TICKER_category file name convention,
+// the directory manifest defaults, its entry for the file and a <file>.json sidecar.
+func (c *manifestCache) resolveCompany(filePath string) (CompanyInfo, error) {
+	info := companyFromFilename(filePath)
+
+	dirManifest, err := c.directory(filepath.Dir(filePath))
+	if err != nil {
+		return info, err
+	}
+	if dirManifest != nil {
+		info = info.merge(dirManifest.Defaults)
+		info = info.merge(dirManifest.Files[filepath.Base(filePath)])
+	}
+
+	sidecarPath := strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".json"
+	content, err := os.ReadFile(sidecarPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return info, fmt.Errorf("failed to read manifest: %w", err)
+	default:
+		var sidecar CompanyInfo
+		if err := json.Unmarshal(content, &sidecar); err != nil {
+			return info, fmt.Errorf("invalid manifest %s: %w", sidecarPath, err)
+		}
+		info = info.merge(sidecar)
+	}
+
+	if info.Currency != "" {
+		currency, err := NormalizeCurrency(info.Currency)
+		if err != nil {
+			return info, fmt.Errorf("invalid manifest for %s: %w", filePath, err)
+		}
+		info.Currency = currency
+	}
+	if info.Name == "" {
+		info.Name = info.Ticker
+	}
+
+	return info, nil
+}
+
+func companyFromFilename(filePath string) CompanyInfo {
+	filename := filepath.Base(filePath)
+	filename = strings.TrimSuffix(filename, filepath.Ext(filename))
+	splitedFilename := strings.Split(filename, "_")
+
+	if len(splitedFilename) < 2 {
+		return CompanyInfo{Ticker: filename, Category: "unknown"}
+	}
+
+	return CompanyInfo{Ticker: splitedFilename[0], Category: splitedFilename[1]}
+}
+
+func (info CompanyInfo) merge(override CompanyInfo) CompanyInfo {
+	if override.Ticker != "" {
+		info.Ticker = override.Ticker
+	}
+	if override.Name != "" {
+		info.Name = override.Name
+	}
+	if override.Category != "" {
+		info.Category = override.Category
+	}
+	if override.Country != "" {
+		info.Country = override.Country
+	}
+	if override.Currency != "" {
+		info.Currency = override.Currency
+	}
+	if override.Tags != nil {
+		info.Tags = override.Tags
+	}
+	return info
+}
+
+func (info CompanyInfo) Company() models.Company {
+	return models.Company{
+		Ticker:   info.Ticker,
+		Name:     info.Name,
+		Category: info.Category,
+		Currency: info.Currency,
+		Country:  info.Country,
+		Tags:     info.Tags,
+	}
+}
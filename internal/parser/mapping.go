@@ -0,0 +1,219 @@
+package parser
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/VxVxN/financialanalyzer/internal/metrics"
+)
+
+const (
+	FieldReportDate = "report_date"
+	FieldCurrency   = "currency"
+)
+
+//go:embed default_mapping.json
+var defaultMappingJSON []byte
+
+type MatchRule struct {
+	Prefix   string   `json:"prefix,omitempty"`
+	Contains string   `json:"contains,omitempty"`
+	Exclude  []string `json:"exclude,omitempty"`
+	Regex    string   `json:"regex,omitempty"`
+	Field    string   `json:"field,omitempty"`
+	Ignore   bool     `json:"ignore,omitempty"`
+
+	re *regexp.Regexp
+}
+
+type ValueMarkers struct {
+	Missing []string `json:"missing"`
+	Cleared []string `json:"cleared"`
+}
+
+// MetricDeclaration registers a metric that is not built in, so rules can map rows to it. A declaration
+// with an expression is a derived metric instead, computed from stored metrics.
+type MetricDeclaration struct {
+	Key         string              `json:"key"`
+	DisplayName string              `json:"display_name,omitempty"`
+	Unit        string              `json:"unit,omitempty"`
+	Kind        metrics.Kind        `json:"kind,omitempty"`
+	Aggregation metrics.Aggregation `json:"aggregation,omitempty"`
+	Expression  string              `json:"expression,omitempty"`
+}
+
+type Mapping struct {
+	Values  ValueMarkers        `json:"values"`
+	Metrics []MetricDeclaration `json:"metrics,omitempty"`
+	Rules   []MatchRule         `json:"rules"`
+}
+
+var defaultValueMarkers = ValueMarkers{
+	Missing: []string{"", "-"},
+	Cleared: []string{"n/a"},
+}
+
+func DefaultMapping() *Mapping {
+	mapping, err := parseMapping(defaultMappingJSON)
+	if err != nil {
+		panic(fmt.Sprintf("parser: invalid default mapping: %v", err))
+	}
+	return mapping
+}
+
+func LoadMapping(path string) (*Mapping, error) {
+	if path == "" {
+		return DefaultMapping(), nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping file: %w", err)
+	}
+
+	mapping, err := parseMapping(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mapping file %s: %w", path, err)
+	}
+
+	return mapping, nil
+}
+
+func parseMapping(content []byte) (*Mapping, error) {
+	var mapping Mapping
+	if err := json.Unmarshal(content, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to decode mapping: %w", err)
+	}
+
+	if mapping.Values.Missing == nil && mapping.Values.Cleared == nil {
+		mapping.Values = defaultValueMarkers
+	}
+
+	for _, declaration := range mapping.Metrics {
+		if err := registerMetric(declaration); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range mapping.Rules {
+		rule := &mapping.Rules[i]
+		if rule.Prefix == "" && rule.Contains == "" && rule.Regex == "" {
+			return nil, fmt.Errorf("rule %d: one of prefix, contains or regex is required", i)
+		}
+		if rule.Regex != "" {
+			re, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: invalid regex: %w", i, err)
+			}
+			rule.re = re
+		}
+		if rule.Ignore || isAttributeField(rule.Field) {
+			continue
+		}
+		def, ok := metrics.Lookup(rule.Field)
+		if !ok {
+			return nil, fmt.Errorf("rule %d: unknown field %q", i, rule.Field)
+		}
+		if def.Derived() {
+			return nil, fmt.Errorf("rule %d: field %q is derived and cannot be imported", i, rule.Field)
+		}
+	}
+
+	return &mapping, nil
+}
+
+// registerMetric registers a declared metric. Loading the same mapping twice is fine as long as the
+// declaration does not change.
+func registerMetric(declaration MetricDeclaration) error {
+	if isAttributeField(declaration.Key) {
+		return fmt.Errorf("metric key %s is reserved", declaration.Key)
+	}
+
+	def := metrics.Definition{
+		Key:         declaration.Key,
+		DisplayName: declaration.DisplayName,
+		Unit:        declaration.Unit,
+		Kind:        declaration.Kind,
+		Aggregation: declaration.Aggregation,
+	}
+	if declaration.Expression != "" {
+		expression, err := metrics.ParseExpression(declaration.Expression)
+		if err != nil {
+			return fmt.Errorf("metric %s: %w", def.Key, err)
+		}
+		def.Expression = expression
+	}
+
+	if existing, ok := metrics.Lookup(def.Key); ok {
+		if (def.DisplayName == "" || def.DisplayName == existing.DisplayName) && def.Unit == existing.Unit &&
+			(def.Kind == "" || def.Kind == existing.Kind) && (def.Aggregation == "" || def.Aggregation == existing.Aggregation) &&
+			expressionSource(def) == expressionSource(existing) {
+			return nil
+		}
+		return fmt.Errorf("metric %s is already registered with a different definition", def.Key)
+	}
+
+	if err := metrics.Register(def); err != nil {
+		return fmt.Errorf("invalid metric declaration: %w", err)
+	}
+	return nil
+}
+
+func expressionSource(def metrics.Definition) string {
+	if !def.Derived() {
+		return ""
+	}
+	return def.Expression.String()
+}
+
+func isAttributeField(field string) bool {
+	return field == FieldReportDate || field == FieldCurrency
+}
+
+func (rule *MatchRule) Match(label string) bool {
+	if rule.Prefix != "" && !strings.HasPrefix(label, rule.Prefix) {
+		return false
+	}
+	if rule.Contains != "" && !strings.Contains(label, rule.Contains) {
+		return false
+	}
+	if rule.re != nil && !rule.re.MatchString(label) {
+		return false
+	}
+	for _, exclude := range rule.Exclude {
+		if strings.Contains(label, exclude) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Mapping) Match(label string) *MatchRule {
+	for i := range m.Rules {
+		if m.Rules[i].Match(label) {
+			return &m.Rules[i]
+		}
+	}
+	return nil
+}
+
+func (m *Mapping) IsMissing(value string) bool {
+	return containsFold(m.Values.Missing, value)
+}
+
+func (m *Mapping) IsCleared(value string) bool {
+	return containsFold(m.Values.Cleared, value)
+}
+
+func containsFold(markers []string, value string) bool {
+	for _, marker := range markers {
+		if strings.EqualFold(marker, value) {
+			return true
+		}
+	}
+	return false
+}
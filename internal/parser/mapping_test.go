@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+// baselineField is the hard-coded row matching that default_mapping.json replaced.
+func baselineField(label string) string {
+	switch {
+	case label == "Дата отчета" || label == "Валюта отчета":
+		return ""
+	case strings.Contains(label, "P/E"):
+		return "pe"
+	case strings.Contains(label, "P/S"):
+		return "ps"
+	case strings.HasPrefix(label, "Долг") && !strings.Contains(label, "EBITDA"):
+		return "debt"
+	case strings.HasPrefix(label, "Чистая прибыль") && !strings.Contains(label, "н/с"):
+		return "net_profit"
+	case strings.HasPrefix(label, "CAPEX") && !strings.Contains(label, "/"):
+		return "capex"
+	}
+
+	prefixes := map[string]string{
+		"Капитализация": "capitalization",
+		"Выручка":       "revenue",
+		"EBITDA":        "ebitda",
+		"ROE":           "roe",
+		"ROA":           "roa",
+		"Опер. расходы": "opex",
+		"Див доход":     "dividends",
+	}
+	for prefix, field := range prefixes {
+		if strings.HasPrefix(label, prefix) {
+			return field
+		}
+	}
+	return ""
+}
+
+func TestDefaultMappingMatchesBaselineRules(t *testing.T) {
+	labels := []string{
+		"Капитализация, млрд руб",
+		"Выручка, млрд руб",
+		"Выручка/Капитализация",
+		"EBITDA, млрд руб",
+		"EBITDA рентаб., %",
+		"Чистая прибыль, млрд руб",
+		"Чистая прибыль н/с, млрд руб",
+		"Долг, млрд руб",
+		"Долг/EBITDA",
+		"Чистый долг, млрд руб",
+		"P/E",
+		"P/S",
+		"P/BV",
+		"EV/EBITDA",
+		"ROE, %",
+		"ROA, %",
+		"CAPEX, млрд руб",
+		"CAPEX/Выручка, %",
+		"Опер. расходы, млрд руб",
+		"Див доход, ао, %",
+		"Дивиденд, руб/акцию",
+		"Активы, млрд руб",
+		"Число акций ао, млн",
+	}
+
+	mapping := DefaultMapping()
+	for _, label := range labels {
+		var field string
+		if rule := mapping.Match(label); rule != nil && !rule.Ignore {
+			field = rule.Field
+		}
+		if want := baselineField(label); field != want {
+			t.Errorf("%q maps to %q, the baseline rules mapped it to %q", label, field, want)
+		}
+	}
+}
+
+func TestDefaultMappingAttributes(t *testing.T) {
+	// The baseline skipped these rows; they now fill the report date and currency of a period.
+	mapping := DefaultMapping()
+	for label, want := range map[string]string{"Дата отчета": FieldReportDate, "Валюта отчета": FieldCurrency} {
+		if rule := mapping.Match(label); rule == nil || rule.Field != want {
+			t.Errorf("%q must map to %q, got %+v", label, want, rule)
+		}
+	}
+}
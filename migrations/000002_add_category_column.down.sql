@@ -1,2 +1,2 @@
 ALTER TABLE company_financials
-    DROP COLUMN category;
\ No newline at end of file
+    DROP COLUMN IF EXISTS category;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE company_financials
+    DROP COLUMN IF EXISTS import_run_id;
+
+DROP TABLE IF EXISTS import_files;
+DROP TABLE IF EXISTS import_runs;
\ No newline at end of file
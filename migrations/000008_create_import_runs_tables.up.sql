@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS import_runs (
+      id SERIAL PRIMARY KEY,
+      source_path TEXT NOT NULL,
+      status VARCHAR(20) NOT NULL DEFAULT 'running',
+      files_total INTEGER NOT NULL DEFAULT 0,
+      files_failed INTEGER NOT NULL DEFAULT 0,
+      rows_parsed INTEGER NOT NULL DEFAULT 0,
+      rows_written INTEGER NOT NULL DEFAULT 0,
+      error TEXT,
+      started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+      finished_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS import_files (
+      id SERIAL PRIMARY KEY,
+      run_id INTEGER NOT NULL REFERENCES import_runs(id) ON DELETE CASCADE,
+      path TEXT NOT NULL,
+      sha256 VARCHAR(64) NOT NULL,
+      size BIGINT NOT NULL DEFAULT 0,
+      rows_parsed INTEGER NOT NULL DEFAULT 0,
+      errors INTEGER NOT NULL DEFAULT 0,
+      status VARCHAR(20) NOT NULL,
+      created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_import_files_run_id ON import_files(run_id);
+CREATE INDEX idx_import_files_path ON import_files(path);
+
+ALTER TABLE company_financials
+    ADD COLUMN IF NOT EXISTS import_run_id INTEGER REFERENCES import_runs(id) ON DELETE SET NULL;
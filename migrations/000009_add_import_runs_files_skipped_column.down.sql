@@ -0,0 +1,2 @@
+ALTER TABLE import_runs
+    DROP COLUMN IF EXISTS files_skipped;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE import_runs
+    ADD COLUMN IF NOT EXISTS files_skipped INTEGER NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE company_financials
+    DROP COLUMN IF EXISTS currency,
+    DROP COLUMN IF EXISTS report_date;
\ No newline at end of file
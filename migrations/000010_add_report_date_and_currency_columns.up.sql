@@ -0,0 +1,3 @@
+ALTER TABLE company_financials
+    ADD COLUMN IF NOT EXISTS report_date DATE,
+    ADD COLUMN IF NOT EXISTS currency VARCHAR(3);
\ No newline at end of file
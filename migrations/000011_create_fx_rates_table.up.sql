@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS fx_rates (
+      id SERIAL PRIMARY KEY,
+      currency VARCHAR(3) NOT NULL,
+      rate_date DATE NOT NULL,
+      rate NUMERIC(20,8) NOT NULL,
+      created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+      UNIQUE(currency, rate_date)
+);
@@ -0,0 +1,10 @@
+DELETE FROM company_financials WHERE period_type <> 'quarter';
+
+ALTER TABLE company_financials
+    DROP CONSTRAINT IF EXISTS company_financials_period_key;
+
+ALTER TABLE company_financials
+    ADD CONSTRAINT company_financials_year_quarter_company_key UNIQUE (year, quarter, company);
+
+ALTER TABLE company_financials
+    DROP COLUMN IF EXISTS period_type;
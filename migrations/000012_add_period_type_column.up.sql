@@ -0,0 +1,8 @@
+ALTER TABLE company_financials
+    ADD COLUMN IF NOT EXISTS period_type VARCHAR(10) NOT NULL DEFAULT 'quarter';
+
+ALTER TABLE company_financials
+    DROP CONSTRAINT IF EXISTS company_financials_year_quarter_company_key;
+
+ALTER TABLE company_financials
+    ADD CONSTRAINT company_financials_period_key UNIQUE (year, quarter, company, period_type);
@@ -0,0 +1,6 @@
+-- 'Q1'..'Q4' labels were already valid before 000013, so only half-year and annual periods,
+-- including LTM rows anchored to them, have to go; merged legacy '1'..'4' rows stay merged.
+ALTER TABLE company_financials
+    DROP CONSTRAINT IF EXISTS company_financials_period_check;
+
+DELETE FROM company_financials WHERE quarter IN ('H1', 'H2', 'FY');
@@ -0,0 +1,38 @@
+-- A company may hold both a legacy '1' row and a 'Q1' row for the same period. The 'Q1' row wins,
+-- its gaps are filled from the legacy row, and the legacy row is dropped before the rename.
+UPDATE company_financials q
+SET capitalization = COALESCE(q.capitalization, l.capitalization),
+    revenue = COALESCE(q.revenue, l.revenue),
+    net_profit = COALESCE(q.net_profit, l.net_profit),
+    ebitda = COALESCE(q.ebitda, l.ebitda),
+    debt = COALESCE(q.debt, l.debt),
+    pe = COALESCE(q.pe, l.pe),
+    ps = COALESCE(q.ps, l.ps),
+    roe = COALESCE(q.roe, l.roe),
+    roa = COALESCE(q.roa, l.roa),
+    capex = COALESCE(q.capex, l.capex),
+    opex = COALESCE(q.opex, l.opex),
+    dividends = COALESCE(q.dividends, l.dividends),
+    report_date = COALESCE(q.report_date, l.report_date),
+    currency = COALESCE(q.currency, l.currency),
+    import_run_id = COALESCE(q.import_run_id, l.import_run_id)
+FROM company_financials l
+WHERE l.quarter IN ('1', '2', '3', '4') AND q.quarter = 'Q' || l.quarter
+  AND q.year = l.year AND q.company = l.company AND q.period_type = l.period_type;
+
+DELETE FROM company_financials l
+USING company_financials q
+WHERE l.quarter IN ('1', '2', '3', '4') AND q.quarter = 'Q' || l.quarter
+  AND q.year = l.year AND q.company = l.company AND q.period_type = l.period_type;
+
+UPDATE company_financials
+    SET quarter = 'Q' || quarter
+    WHERE quarter IN ('1', '2', '3', '4');
+
+ALTER TABLE company_financials
+    ADD CONSTRAINT company_financials_period_check CHECK (
+        (period_type = 'quarter' AND quarter IN ('Q1', 'Q2', 'Q3', 'Q4')) OR
+        (period_type = 'half' AND quarter IN ('H1', 'H2')) OR
+        (period_type = 'year' AND quarter = 'FY') OR
+        (period_type = 'ltm' AND quarter IN ('Q1', 'Q2', 'Q3', 'Q4', 'H1', 'H2', 'FY'))
+    );
@@ -0,0 +1,29 @@
+ALTER TABLE company_colors ADD COLUMN company VARCHAR(100);
+UPDATE company_colors cc SET company = c.ticker FROM companies c WHERE c.id = cc.company_id;
+ALTER TABLE company_colors
+    ALTER COLUMN company SET NOT NULL,
+    ADD CONSTRAINT company_colors_company_key UNIQUE (company),
+    DROP COLUMN company_id;
+CREATE INDEX idx_company_colors_company ON company_colors(company);
+
+ALTER TABLE company_notes ADD COLUMN company VARCHAR(100);
+UPDATE company_notes cn SET company = c.ticker FROM companies c WHERE c.id = cn.company_id;
+ALTER TABLE company_notes
+    ALTER COLUMN company SET NOT NULL,
+    ADD CONSTRAINT company_notes_company_key UNIQUE (company),
+    DROP COLUMN company_id;
+CREATE INDEX idx_company_notes_company ON company_notes(company);
+
+ALTER TABLE company_financials
+    ADD COLUMN company VARCHAR(100),
+    ADD COLUMN category VARCHAR(100);
+UPDATE company_financials cf SET company = c.ticker, category = c.category FROM companies c WHERE c.id = cf.company_id;
+ALTER TABLE company_financials
+    ALTER COLUMN company SET NOT NULL,
+    ALTER COLUMN category SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS company_financials_period_key;
+ALTER TABLE company_financials
+    ADD CONSTRAINT company_financials_period_key UNIQUE (year, quarter, company, period_type),
+    DROP COLUMN company_id;
+
+DROP TABLE IF EXISTS companies;
@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS companies (
+    id SERIAL PRIMARY KEY,
+    ticker VARCHAR(100) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    category VARCHAR(100) NOT NULL DEFAULT 'unknown',
+    currency VARCHAR(3),
+    country VARCHAR(100),
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_companies_category ON companies(category);
+
+INSERT INTO companies (ticker, name, category)
+SELECT DISTINCT ON (company) company, company, category
+FROM company_financials
+ORDER BY company, id DESC
+ON CONFLICT (ticker) DO NOTHING;
+
+INSERT INTO companies (ticker, name)
+SELECT company, company FROM company_notes
+ON CONFLICT (ticker) DO NOTHING;
+
+INSERT INTO companies (ticker, name)
+SELECT company, company FROM company_colors
+ON CONFLICT (ticker) DO NOTHING;
+
+ALTER TABLE company_financials
+    ADD COLUMN company_id INTEGER REFERENCES companies(id) ON DELETE CASCADE;
+UPDATE company_financials cf SET company_id = c.id FROM companies c WHERE c.ticker = cf.company;
+ALTER TABLE company_financials
+    ALTER COLUMN company_id SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS company_financials_period_key;
+ALTER TABLE company_financials
+    ADD CONSTRAINT company_financials_period_key UNIQUE (year, quarter, company_id, period_type),
+    DROP COLUMN company,
+    DROP COLUMN category;
+
+ALTER TABLE company_notes
+    ADD COLUMN company_id INTEGER REFERENCES companies(id) ON DELETE CASCADE;
+UPDATE company_notes cn SET company_id = c.id FROM companies c WHERE c.ticker = cn.company;
+DROP INDEX IF EXISTS idx_company_notes_company;
+ALTER TABLE company_notes
+    ALTER COLUMN company_id SET NOT NULL,
+    ADD CONSTRAINT company_notes_company_id_key UNIQUE (company_id),
+    DROP COLUMN company;
+
+ALTER TABLE company_colors
+    ADD COLUMN company_id INTEGER REFERENCES companies(id) ON DELETE CASCADE;
+UPDATE company_colors cc SET company_id = c.id FROM companies c WHERE c.ticker = cc.company;
+DROP INDEX IF EXISTS idx_company_colors_company;
+ALTER TABLE company_colors
+    ALTER COLUMN company_id SET NOT NULL,
+    ADD CONSTRAINT company_colors_company_id_key UNIQUE (company_id),
+    DROP COLUMN company;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS company_aliases (
+    alias VARCHAR(100) PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_company_aliases_company_id ON company_aliases(company_id);
@@ -0,0 +1,6 @@
+DELETE FROM companies WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_companies_deleted_at;
+
+ALTER TABLE companies
+    DROP COLUMN IF EXISTS deleted_at;
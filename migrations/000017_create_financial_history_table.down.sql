@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS company_financials_history ON company_financials;
+
+DROP FUNCTION IF EXISTS record_financial_history();
+
+DROP TABLE IF EXISTS financial_history;
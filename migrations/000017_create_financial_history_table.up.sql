@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS financial_history (
+      id BIGSERIAL PRIMARY KEY,
+      company_id INTEGER NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+      year INTEGER NOT NULL,
+      quarter VARCHAR(2) NOT NULL,
+      period_type VARCHAR(10) NOT NULL,
+      metric VARCHAR(50) NOT NULL,
+      old_value NUMERIC,
+      new_value NUMERIC,
+      source VARCHAR(20) NOT NULL,
+      import_run_id INTEGER REFERENCES import_runs(id) ON DELETE SET NULL,
+      changed_by TEXT NOT NULL,
+      changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_financial_history_cell ON financial_history(company_id, year, quarter, period_type, metric);
+
+-- Every metric column of company_financials is compared, so metrics added later are tracked without
+-- touching the trigger. Writers describe the change with set_config('app.change_source' / 'app.changed_by', ..., true).
+CREATE OR REPLACE FUNCTION record_financial_history() RETURNS TRIGGER AS $$
+DECLARE
+    change_source TEXT := COALESCE(NULLIF(current_setting('app.change_source', true), ''), 'manual');
+    changed_by TEXT := COALESCE(NULLIF(current_setting('app.changed_by', true), ''), current_user);
+    old_row JSONB := '{}';
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+
+    INSERT INTO financial_history (company_id, year, quarter, period_type, metric, old_value, new_value, source, import_run_id, changed_by)
+    SELECT NEW.company_id, NEW.year, NEW.quarter, NEW.period_type, n.key,
+           (old_row ->> n.key)::NUMERIC, (n.value #>> '{}')::NUMERIC, change_source,
+           CASE WHEN change_source = 'import' THEN NEW.import_run_id END, changed_by
+    FROM jsonb_each(to_jsonb(NEW)) n
+    WHERE n.key NOT IN ('id', 'year', 'quarter', 'period_type', 'company_id', 'import_run_id',
+                        'report_date', 'currency', 'created_at')
+      AND COALESCE(old_row -> n.key, 'null') IS DISTINCT FROM n.value;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER company_financials_history
+    AFTER INSERT OR UPDATE ON company_financials
+    FOR EACH ROW EXECUTE FUNCTION record_financial_history();
@@ -0,0 +1,80 @@
+DROP TRIGGER IF EXISTS financial_values_history ON financial_values;
+
+DROP FUNCTION IF EXISTS record_financial_value_history();
+
+ALTER TABLE company_financials
+    ADD COLUMN IF NOT EXISTS capitalization NUMERIC(15,2),
+    ADD COLUMN IF NOT EXISTS revenue NUMERIC(15,2),
+    ADD COLUMN IF NOT EXISTS net_profit NUMERIC(15,2),
+    ADD COLUMN IF NOT EXISTS ebitda NUMERIC(15,2),
+    ADD COLUMN IF NOT EXISTS debt NUMERIC(15,2),
+    ADD COLUMN IF NOT EXISTS pe NUMERIC(10,2),
+    ADD COLUMN IF NOT EXISTS ps NUMERIC(10,2),
+    ADD COLUMN IF NOT EXISTS roe NUMERIC(10,2),
+    ADD COLUMN IF NOT EXISTS roa NUMERIC(10,2),
+    ADD COLUMN IF NOT EXISTS capex NUMERIC(15,2),
+    ADD COLUMN IF NOT EXISTS opex NUMERIC(15,2),
+    ADD COLUMN IF NOT EXISTS dividends NUMERIC(10,2);
+
+-- Only the built-in metrics have a column to go back to; values of metrics registered later are dropped.
+UPDATE company_financials cf
+SET capitalization = v.capitalization,
+    revenue = v.revenue,
+    net_profit = v.net_profit,
+    ebitda = v.ebitda,
+    debt = v.debt,
+    pe = v.pe,
+    ps = v.ps,
+    roe = v.roe,
+    roa = v.roa,
+    capex = v.capex,
+    opex = v.opex,
+    dividends = v.dividends
+FROM (
+    SELECT financial_id,
+           MAX(value) FILTER (WHERE metric_key = 'capitalization') AS capitalization,
+           MAX(value) FILTER (WHERE metric_key = 'revenue') AS revenue,
+           MAX(value) FILTER (WHERE metric_key = 'net_profit') AS net_profit,
+           MAX(value) FILTER (WHERE metric_key = 'ebitda') AS ebitda,
+           MAX(value) FILTER (WHERE metric_key = 'debt') AS debt,
+           MAX(value) FILTER (WHERE metric_key = 'pe') AS pe,
+           MAX(value) FILTER (WHERE metric_key = 'ps') AS ps,
+           MAX(value) FILTER (WHERE metric_key = 'roe') AS roe,
+           MAX(value) FILTER (WHERE metric_key = 'roa') AS roa,
+           MAX(value) FILTER (WHERE metric_key = 'capex') AS capex,
+           MAX(value) FILTER (WHERE metric_key = 'opex') AS opex,
+           MAX(value) FILTER (WHERE metric_key = 'dividends') AS dividends
+    FROM financial_values
+    GROUP BY financial_id
+) v
+WHERE v.financial_id = cf.id;
+
+DROP TABLE IF EXISTS financial_values;
+
+-- The history trigger of 000017.
+CREATE OR REPLACE FUNCTION record_financial_history() RETURNS TRIGGER AS $$
+DECLARE
+    change_source TEXT := COALESCE(NULLIF(current_setting('app.change_source', true), ''), 'manual');
+    changed_by TEXT := COALESCE(NULLIF(current_setting('app.changed_by', true), ''), current_user);
+    old_row JSONB := '{}';
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+
+    INSERT INTO financial_history (company_id, year, quarter, period_type, metric, old_value, new_value, source, import_run_id, changed_by)
+    SELECT NEW.company_id, NEW.year, NEW.quarter, NEW.period_type, n.key,
+           (old_row ->> n.key)::NUMERIC, (n.value #>> '{}')::NUMERIC, change_source,
+           CASE WHEN change_source = 'import' THEN NEW.import_run_id END, changed_by
+    FROM jsonb_each(to_jsonb(NEW)) n
+    WHERE n.key NOT IN ('id', 'year', 'quarter', 'period_type', 'company_id', 'import_run_id',
+                        'report_date', 'currency', 'created_at')
+      AND COALESCE(old_row -> n.key, 'null') IS DISTINCT FROM n.value;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER company_financials_history
+    AFTER INSERT OR UPDATE ON company_financials
+    FOR EACH ROW EXECUTE FUNCTION record_financial_history();
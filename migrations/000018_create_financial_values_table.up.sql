@@ -0,0 +1,87 @@
+CREATE TABLE IF NOT EXISTS financial_values (
+      financial_id INTEGER NOT NULL REFERENCES company_financials(id) ON DELETE CASCADE,
+      metric_key VARCHAR(50) NOT NULL,
+      value NUMERIC(20,2) NOT NULL,
+      PRIMARY KEY (financial_id, metric_key)
+);
+
+CREATE INDEX idx_financial_values_metric_key ON financial_values(metric_key);
+
+INSERT INTO financial_values (financial_id, metric_key, value)
+SELECT cf.id, v.metric_key, v.value
+FROM company_financials cf
+CROSS JOIN LATERAL (VALUES
+    ('capitalization', cf.capitalization),
+    ('revenue', cf.revenue),
+    ('net_profit', cf.net_profit),
+    ('ebitda', cf.ebitda),
+    ('debt', cf.debt),
+    ('pe', cf.pe),
+    ('ps', cf.ps),
+    ('roe', cf.roe),
+    ('roa', cf.roa),
+    ('capex', cf.capex),
+    ('opex', cf.opex),
+    ('dividends', cf.dividends)
+) AS v(metric_key, value)
+WHERE v.value IS NOT NULL;
+
+DROP TRIGGER IF EXISTS company_financials_history ON company_financials;
+
+DROP FUNCTION IF EXISTS record_financial_history();
+
+ALTER TABLE company_financials
+    DROP COLUMN IF EXISTS capitalization,
+    DROP COLUMN IF EXISTS revenue,
+    DROP COLUMN IF EXISTS net_profit,
+    DROP COLUMN IF EXISTS ebitda,
+    DROP COLUMN IF EXISTS debt,
+    DROP COLUMN IF EXISTS pe,
+    DROP COLUMN IF EXISTS ps,
+    DROP COLUMN IF EXISTS roe,
+    DROP COLUMN IF EXISTS roa,
+    DROP COLUMN IF EXISTS capex,
+    DROP COLUMN IF EXISTS opex,
+    DROP COLUMN IF EXISTS dividends;
+
+-- company_financials now only holds the period of a company; history follows the values. Values deleted
+-- together with their period or company have no period left to attach the change to and are skipped.
+CREATE OR REPLACE FUNCTION record_financial_value_history() RETURNS TRIGGER AS $$
+DECLARE
+    change_source TEXT := COALESCE(NULLIF(current_setting('app.change_source', true), ''), 'manual');
+    changed_by TEXT := COALESCE(NULLIF(current_setting('app.changed_by', true), ''), current_user);
+    v_financial_id INTEGER;
+    v_metric_key TEXT;
+    v_old_value NUMERIC;
+    v_new_value NUMERIC;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        v_financial_id := OLD.financial_id;
+        v_metric_key := OLD.metric_key;
+        v_old_value := OLD.value;
+    ELSE
+        v_financial_id := NEW.financial_id;
+        v_metric_key := NEW.metric_key;
+        v_new_value := NEW.value;
+        IF TG_OP = 'UPDATE' THEN
+            v_old_value := OLD.value;
+        END IF;
+    END IF;
+
+    IF v_old_value IS NOT DISTINCT FROM v_new_value THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO financial_history (company_id, year, quarter, period_type, metric, old_value, new_value, source, import_run_id, changed_by)
+    SELECT cf.company_id, cf.year, cf.quarter, cf.period_type, v_metric_key, v_old_value, v_new_value, change_source,
+           CASE WHEN change_source = 'import' THEN cf.import_run_id END, changed_by
+    FROM company_financials cf
+    WHERE cf.id = v_financial_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER financial_values_history
+    AFTER INSERT OR UPDATE OR DELETE ON financial_values
+    FOR EACH ROW EXECUTE FUNCTION record_financial_value_history();
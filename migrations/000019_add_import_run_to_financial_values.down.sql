@@ -0,0 +1,39 @@
+-- The history function of 000018.
+CREATE OR REPLACE FUNCTION record_financial_value_history() RETURNS TRIGGER AS $$
+DECLARE
+    change_source TEXT := COALESCE(NULLIF(current_setting('app.change_source', true), ''), 'manual');
+    changed_by TEXT := COALESCE(NULLIF(current_setting('app.changed_by', true), ''), current_user);
+    v_financial_id INTEGER;
+    v_metric_key TEXT;
+    v_old_value NUMERIC;
+    v_new_value NUMERIC;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        v_financial_id := OLD.financial_id;
+        v_metric_key := OLD.metric_key;
+        v_old_value := OLD.value;
+    ELSE
+        v_financial_id := NEW.financial_id;
+        v_metric_key := NEW.metric_key;
+        v_new_value := NEW.value;
+        IF TG_OP = 'UPDATE' THEN
+            v_old_value := OLD.value;
+        END IF;
+    END IF;
+
+    IF v_old_value IS NOT DISTINCT FROM v_new_value THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO financial_history (company_id, year, quarter, period_type, metric, old_value, new_value, source, import_run_id, changed_by)
+    SELECT cf.company_id, cf.year, cf.quarter, cf.period_type, v_metric_key, v_old_value, v_new_value, change_source,
+           CASE WHEN change_source = 'import' THEN cf.import_run_id END, changed_by
+    FROM company_financials cf
+    WHERE cf.id = v_financial_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE financial_values
+    DROP COLUMN IF EXISTS import_run_id;
@@ -0,0 +1,49 @@
+-- Every value records the run that wrote it. Existing values only know the last run that touched
+-- their period, which is the best provenance available for them.
+ALTER TABLE financial_values
+    ADD COLUMN IF NOT EXISTS import_run_id INTEGER REFERENCES import_runs(id) ON DELETE SET NULL;
+
+UPDATE financial_values v
+SET import_run_id = cf.import_run_id
+FROM company_financials cf
+WHERE cf.id = v.financial_id;
+
+-- Written values carry their run; a removal takes the run from set_config('app.import_run_id', ..., true).
+CREATE OR REPLACE FUNCTION record_financial_value_history() RETURNS TRIGGER AS $$
+DECLARE
+    change_source TEXT := COALESCE(NULLIF(current_setting('app.change_source', true), ''), 'manual');
+    changed_by TEXT := COALESCE(NULLIF(current_setting('app.changed_by', true), ''), current_user);
+    v_financial_id INTEGER;
+    v_metric_key TEXT;
+    v_old_value NUMERIC;
+    v_new_value NUMERIC;
+    v_import_run_id INTEGER;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        v_financial_id := OLD.financial_id;
+        v_metric_key := OLD.metric_key;
+        v_old_value := OLD.value;
+        v_import_run_id := NULLIF(current_setting('app.import_run_id', true), '')::INTEGER;
+    ELSE
+        v_financial_id := NEW.financial_id;
+        v_metric_key := NEW.metric_key;
+        v_new_value := NEW.value;
+        v_import_run_id := NEW.import_run_id;
+        IF TG_OP = 'UPDATE' THEN
+            v_old_value := OLD.value;
+        END IF;
+    END IF;
+
+    IF v_old_value IS NOT DISTINCT FROM v_new_value THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO financial_history (company_id, year, quarter, period_type, metric, old_value, new_value, source, import_run_id, changed_by)
+    SELECT cf.company_id, cf.year, cf.quarter, cf.period_type, v_metric_key, v_old_value, v_new_value, change_source,
+           CASE WHEN change_source = 'import' THEN v_import_run_id END, changed_by
+    FROM company_financials cf
+    WHERE cf.id = v_financial_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
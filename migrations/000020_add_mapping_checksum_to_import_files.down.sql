@@ -0,0 +1,2 @@
+ALTER TABLE import_files
+    DROP COLUMN IF EXISTS mapping_sha256;
@@ -0,0 +1,3 @@
+-- Files imported before this migration have no mapping checksum, so the next import parses them again.
+ALTER TABLE import_files
+    ADD COLUMN IF NOT EXISTS mapping_sha256 VARCHAR(64) NOT NULL DEFAULT '';